@@ -10,13 +10,16 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
+	"path/filepath"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gurleensethi/yurl/internal/har"
 	"github.com/gurleensethi/yurl/internal/logger"
+	"github.com/gurleensethi/yurl/internal/placeholder"
 	"github.com/gurleensethi/yurl/internal/variable"
 	"github.com/gurleensethi/yurl/pkg/models"
 	"github.com/gurleensethi/yurl/pkg/styles"
@@ -25,8 +28,7 @@ import (
 
 var (
 	ErrParsingExports = errors.New("error parsing exports")
-
-	inputRegex = regexp.MustCompile(`{{\s+?([a-zA-Z0-9]+):?(string|int|float|bool)?\s+?}}`)
+	ErrGraphQL        = errors.New("graphql request failed")
 )
 
 // App represents the main application for performing
@@ -37,6 +39,16 @@ type App struct {
 
 	// Inital variables that app is initialized with.
 	Variables variable.Variables
+
+	// Recorder, when set, records every request sent along with its response.
+	Recorder *har.Recorder
+
+	// Input, when set, returns the value of variables that are not defined
+	// instead of prompting the user for it.
+	Input func(key string, inputType string) (string, error)
+
+	// Functions, when set, are the functions placeholders call instead of placeholder.Functions.
+	Functions map[string]placeholder.Function
 }
 
 func New(template models.HttpTemplate, vars variable.Variables) *App {
@@ -49,11 +61,21 @@ func New(template models.HttpTemplate, vars variable.Variables) *App {
 type ExecuteRequestOpts struct {
 	Variables variable.Variables
 	Verbose   bool
+
+	// Output is the file the body of the response is saved to, it takes precedence
+	// over saveTo of the request. Resume continues a partially saved download.
+	Output string
+	Resume bool
 }
 
 func (a *App) ListRequests(ctx context.Context) error {
 	keys := make([]string, 0, len(a.HTTPTemplate.Requests))
-	for name := range a.HTTPTemplate.Requests {
+	for name, request := range a.HTTPTemplate.Requests {
+		// Abstract requests only exist to be extended
+		if request.Abstract {
+			continue
+		}
+
 		keys = append(keys, name)
 	}
 
@@ -66,7 +88,7 @@ func (a *App) ListRequests(ctx context.Context) error {
 		description := styles.Description.Render(request.Description)
 
 		fmt.Println(name, description)
-		fmt.Println("  ", styles.Url.Bold(false).Render(request.Method+" "+request.Path))
+		fmt.Println("  ", styles.Url.Bold(false).Render(request.Method+" "+a.displayURL(request)))
 
 		if i < len(keys)-1 {
 			fmt.Println()
@@ -82,44 +104,55 @@ func (a *App) ExecuteRequest(ctx context.Context, requestName string, opts Execu
 		return errors.New("request not found")
 	}
 
+	if request.Abstract {
+		return fmt.Errorf("request '%s' is abstract and can't be executed", requestName)
+	}
+
 	request.Sanitize()
 
 	requestExecutionChain := a.getRequestExecutionChain(request)
 
-	// We store response of each request
-	responses := make(map[string]*models.HttpResponse)
-
-	for i, request := range requestExecutionChain {
-		vars := opts.Variables
-
-		// For all pre-requests required by this request, add exported variables to vars.
-		for _, preRequest := range request.PreRequests {
-			if preRequestResponse, ok := responses[preRequest.Name]; ok {
-				for key, value := range preRequestResponse.Exports {
-					vars.Add(variable.Variable{
-						Key:    key,
-						Value:  value,
-						Source: variable.SourceExports,
-					})
-				}
-			}
-		}
-
-		_, response, err := a.executeRequest(ctx, request, vars, opts.Verbose)
+	last := &requestExecutionChain[len(requestExecutionChain)-1]
+	if opts.Output != "" {
+		// The output is relative to the working directory, not to the request file
+		output, err := filepath.Abs(opts.Output)
 		if err != nil {
 			return err
 		}
 
-		responses[request.Name] = response
+		last.SaveTo = &models.SaveTo{Path: output}
+	}
+	if opts.Resume && last.SaveTo != nil {
+		last.SaveTo = &models.SaveTo{Path: last.SaveTo.Path, Resume: true}
+	}
 
-		isFirstOrLast := i == 0 || i == len(requestExecutionChain)-1
-		if opts.Verbose && !isFirstOrLast {
-			fmt.Println(styles.Divider.Render("------------------------------------------------------------------------------"))
-		}
+	// Variables passed in opts take precedence over the ones app was initialized with.
+	vars := variable.NewVariables()
+	vars.Merge(a.Variables)
+	vars.Merge(opts.Variables)
+
+	responses, err := a.executeRequestChain(ctx, requestExecutionChain, vars, opts.Verbose, true)
+	if err != nil {
+		return err
 	}
 
-	if !opts.Verbose {
-		fmt.Println(string(responses[request.Name].RawBody))
+	response := responses[request.Name]
+
+	switch {
+	case response.SavedTo != "":
+		fmt.Fprintf(os.Stderr, "Saved %s to %s\n", formatSize(response.SavedSize), response.SavedTo)
+	case opts.Verbose || response.Streamed:
+		// The body is logged, events of streamed responses are printed as they arrive
+	case response.IsBinary() && isTerminal(os.Stdout):
+		fmt.Fprintf(os.Stderr, "Binary body of %s not printed, use --output to save it to a file\n", formatSize(int64(len(response.RawBody))))
+	case response.IsBinary():
+		// Binary bodies are written as they are, without a new line
+		_, err := os.Stdout.Write(response.RawBody)
+		if err != nil {
+			return err
+		}
+	default:
+		fmt.Println(string(response.RawBody))
 	}
 
 	// vars := opts.Variables
@@ -156,6 +189,146 @@ func (a *App) ExecuteRequest(ctx context.Context, requestName string, opts Execu
 	return nil
 }
 
+type BuildRequestOpts struct {
+	Variables variable.Variables
+
+	// NoPreRequests skips executing the pre-requests, variables they
+	// export are replaced with placeholders like <token>.
+	NoPreRequests bool
+}
+
+// BuildRequest builds the http request without sending it. Pre-requests are executed,
+// without any output, to resolve the variables exported by them.
+func (a *App) BuildRequest(ctx context.Context, requestName string, opts BuildRequestOpts) (*models.HttpRequest, error) {
+	request, ok := a.HTTPTemplate.Requests[requestName]
+	if !ok {
+		return nil, errors.New("request not found")
+	}
+
+	if request.Abstract {
+		return nil, fmt.Errorf("request '%s' is abstract and can't be built", requestName)
+	}
+
+	request.Sanitize()
+
+	vars := variable.NewVariables()
+	vars.Merge(a.Variables)
+	vars.Merge(opts.Variables)
+
+	if opts.NoPreRequests {
+		for _, preRequest := range request.PreRequests {
+			for key := range a.HTTPTemplate.Requests[preRequest.Name].Exports {
+				// Variables passed explicitly are used over placeholders.
+				if _, ok := vars.Get(key); ok {
+					continue
+				}
+
+				vars.Add(variable.Variable{
+					Key:    key,
+					Value:  "<" + key + ">",
+					Source: variable.SourcePlaceholder,
+				})
+			}
+		}
+
+		return a.buildRequest(ctx, request, vars)
+	}
+
+	requestExecutionChain := a.getRequestExecutionChain(request)
+	preRequests := requestExecutionChain[:len(requestExecutionChain)-1]
+
+	responses, err := a.executeRequestChain(ctx, preRequests, vars, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	addExportedVariables(vars, request, responses)
+
+	return a.buildRequest(ctx, request, vars)
+}
+
+// BuildRequestChain builds the request along with all its pre-requests, in the order they
+// are executed, without sending any of them. Variables exported by the pre-requests are
+// not defined, Input is used to get their values.
+func (a *App) BuildRequestChain(ctx context.Context, requestName string, vars variable.Variables) ([]*models.HttpRequest, error) {
+	request, ok := a.HTTPTemplate.Requests[requestName]
+	if !ok {
+		return nil, errors.New("request not found")
+	}
+
+	if request.Abstract {
+		return nil, fmt.Errorf("request '%s' is abstract and can't be built", requestName)
+	}
+
+	request.Sanitize()
+
+	buildVars := variable.NewVariables()
+	buildVars.Merge(a.Variables)
+	buildVars.Merge(vars)
+
+	var requests []*models.HttpRequest
+	built := make(map[string]bool)
+
+	for _, chainRequest := range a.getRequestExecutionChain(request) {
+		// Pre-requests shared by multiple requests in the chain are only built once
+		if built[chainRequest.Name] {
+			continue
+		}
+		built[chainRequest.Name] = true
+
+		httpRequest, err := a.buildRequest(ctx, chainRequest, buildVars)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, httpRequest)
+	}
+
+	return requests, nil
+}
+
+// executeRequestChain executes the requests in order, variables exported by the
+// pre-requests of a request are added to vars before it is executed.
+func (a *App) executeRequestChain(ctx context.Context, chain []models.HttpRequestTemplate, vars variable.Variables, verbose bool, printLast bool) (map[string]*models.HttpResponse, error) {
+	// We store response of each request
+	responses := make(map[string]*models.HttpResponse)
+
+	for i, request := range chain {
+		addExportedVariables(vars, request, responses)
+
+		isLast := i == len(chain)-1
+
+		_, response, err := a.executeRequest(ctx, request, vars, verbose, printLast && isLast)
+		if err != nil {
+			return nil, err
+		}
+
+		responses[request.Name] = response
+
+		isFirstOrLast := i == 0 || i == len(chain)-1
+		if verbose && !isFirstOrLast {
+			fmt.Println(styles.Divider.Render("------------------------------------------------------------------------------"))
+		}
+	}
+
+	return responses, nil
+}
+
+// addExportedVariables adds the variables exported by the pre-requests of the request to vars.
+func addExportedVariables(vars variable.Variables, request models.HttpRequestTemplate, responses map[string]*models.HttpResponse) {
+	for _, preRequest := range request.PreRequests {
+		if preRequestResponse, ok := responses[preRequest.Name]; ok {
+			for key, value := range preRequestResponse.Exports {
+				vars.Add(variable.Variable{
+					Key:    key,
+					Value:  value,
+					Source: variable.SourceExports,
+				})
+			}
+		}
+	}
+}
+
 func (a *App) getRequestExecutionChain(requestTemplate models.HttpRequestTemplate) []models.HttpRequestTemplate {
 	var queue = []string{requestTemplate.Name}
 	var chain = []models.HttpRequestTemplate{}
@@ -178,19 +351,49 @@ func (a *App) getRequestExecutionChain(requestTemplate models.HttpRequestTemplat
 	return chain
 }
 
-func (a *App) executeRequest(ctx context.Context, requestTemplate models.HttpRequestTemplate, vars variable.Variables, verbose bool) (*http.Request, *models.HttpResponse, error) {
+// executeRequest sends the request, events of streamed responses are printed as they
+// arrive when printStream is set.
+func (a *App) executeRequest(ctx context.Context, requestTemplate models.HttpRequestTemplate, vars variable.Variables, verbose bool, printStream bool) (*http.Request, *models.HttpResponse, error) {
 	httpRequest, err := a.buildRequest(ctx, requestTemplate, vars)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	httpReq := httpRequest.RawRequest
+	saveTo := httpRequest.Template.SaveTo
+
+	// Downloads are resumed from the size of the file already written
+	var resumeFrom int64
+	if saveTo != nil {
+		resumeFrom, err = resumeOffset(*saveTo, httpReq)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	if verbose {
 		logger.LogHttpRequest(ctx, httpRequest)
 	}
 
-	httpClient := http.Client{}
+	if requestTemplate.WebSocket != nil {
+		httpResponse, err := a.executeWebSocket(ctx, httpRequest, *requestTemplate.WebSocket, vars, verbose)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if a.Recorder != nil {
+			err := a.Recorder.Record(httpResponse)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return httpReq, httpResponse, nil
+	}
+
+	startedAt := time.Now()
+
+	httpClient := newHTTPClient(httpRequest.Template.Socket)
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
 		return nil, nil, err
@@ -198,9 +401,39 @@ func (a *App) executeRequest(ctx context.Context, requestTemplate models.HttpReq
 
 	defer httpResp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, nil, err
+	var bodyBytes []byte
+	var savedSize int64
+
+	// Streamed responses are read event by event, exports are read from one of the
+	// events. Like exports, the error of the stream is returned once it is logged.
+	streamed := saveTo == nil && isStreamed(requestTemplate.Stream, httpResp.Header)
+	var event string
+	var streamErr error
+
+	// The body of saved and streamed responses is not logged as a whole
+	if verbose && (saveTo != nil || streamed) {
+		logger.LogHttpResponseHead(ctx, httpResp)
+		fmt.Println()
+	}
+
+	switch {
+	case saveTo != nil:
+		savedSize, err = saveResponse(httpResp, saveTo.Path, resumeFrom)
+		if err != nil {
+			return nil, nil, err
+		}
+	case streamed:
+		stream := models.Stream{}
+		if requestTemplate.Stream != nil {
+			stream = *requestTemplate.Stream
+		}
+
+		bodyBytes, event, streamErr = a.readStream(httpResp, stream, vars, verbose || printStream)
+	default:
+		bodyBytes, err = io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	httpResponse := &models.HttpResponse{
@@ -208,34 +441,65 @@ func (a *App) executeRequest(ctx context.Context, requestTemplate models.HttpReq
 		RawResponse: httpResp,
 		RawBody:     bodyBytes,
 		Exports:     make(map[string]any),
+		Streamed:    streamed,
+		SavedSize:   savedSize,
+		StartedAt:   startedAt,
+		Duration:    time.Since(startedAt),
+	}
+
+	if saveTo != nil {
+		httpResponse.SavedTo = saveTo.Path
+	}
+
+	if a.Recorder != nil {
+		err := a.Recorder.Record(httpResponse)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Capturing the exports error to process later on because regardless if there is an error
 	// parsing the exports we still want to log the response.
 	var exportsErr error
 
-	for name, export := range requestTemplate.Exports {
-		if export.JSON != "" {
-			var parsedBody interface{}
-			exportsErr = json.Unmarshal(bodyBytes, &parsedBody)
-			if err != nil {
-				exportsErr = err
-				break
-			}
+	exportBody := bodyBytes
+	if streamed {
+		exportBody = []byte(event)
+	}
 
-			var value any
-			value, err := jsonpath.Read(parsedBody, export.JSON)
-			if err != nil {
-				exportsErr = err
-				break
-			}
+	// Exports of graphql requests are read from the data of the response
+	if requestTemplate.GraphQL != nil {
+		exportBody = graphQLData(exportBody)
+	}
+
+	// Responses saved to a file are not read back, they have no exports
+	exports := requestTemplate.Exports
+	if saveTo != nil {
+		exports = nil
+	}
 
-			httpResponse.Exports[name] = value
+	for name, export := range exports {
+		value, err := exportValue(exportBody, export)
+		if err != nil {
+			exportsErr = fmt.Errorf("export '%s': %w", name, err)
+			break
 		}
+
+		httpResponse.Exports[name] = value
 	}
-	if verbose {
+	if verbose && (saveTo != nil || streamed) {
+		logger.LogExports(ctx, httpResponse.Exports)
+	} else if verbose {
 		logger.LogHttpResponse(ctx, httpResponse)
 	}
+	if streamErr != nil {
+		return nil, nil, streamErr
+	}
+	if requestTemplate.GraphQL != nil {
+		if err := graphQLErrors(bodyBytes); err != nil {
+			return nil, nil, err
+		}
+	}
 	if exportsErr != nil {
 		return nil, nil, fmt.Errorf("%w: %w", ErrParsingExports, exportsErr)
 	}
@@ -243,20 +507,41 @@ func (a *App) executeRequest(ctx context.Context, requestTemplate models.HttpReq
 	return httpReq, httpResponse, nil
 }
 
+// exportValue reads the value of the export from the body of the response.
+func exportValue(body []byte, export models.Export) (any, error) {
+	switch {
+	case export.JSON != "":
+		var parsedBody any
+		err := json.Unmarshal(body, &parsedBody)
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonpath.Read(parsedBody, export.JSON)
+	case export.XPath != "":
+		return xpathValue(body, export.XPath)
+	}
+
+	return nil, errors.New("json or xpath is required")
+}
+
+// findVariables returns the variables used by the placeholders in s, along with their type.
 func findVariables(s string) []string {
-	matches := inputRegex.FindAllStringSubmatch(s, -1)
-	if len(matches) == 0 {
+	placeholders, err := placeholder.Parse(s)
+	if err != nil {
 		return nil
 	}
 
-	vars := make([]string, 0)
-	for _, match := range matches {
-		variable := match[1]
-		if len(match) > 2 && match[2] != "" {
-			variable += " (" + match[2] + ")"
-		}
+	var vars []string
+	for _, p := range placeholders {
+		for _, v := range p.Variables(nil) {
+			name := v.Name
+			if v.Type != "" {
+				name += " (" + v.Type + ")"
+			}
 
-		vars = append(vars, variable)
+			vars = append(vars, name)
+		}
 	}
 
 	return vars
@@ -290,7 +575,27 @@ func (a *App) ListRequestVariables(ctx context.Context, request models.HttpReque
 
 	// Find variables in the body
 	bodyVars := findVariables(request.Body)
-	bodyVars = append(bodyVars, findVariables(request.JsonBody)...)
+	jsonBody, _ := request.JsonBody.Render(models.JSONScalar)
+	bodyVars = append(bodyVars, findVariables(jsonBody)...)
+	bodyVars = append(bodyVars, findVariables(request.XmlBody)...)
+
+	if request.GraphQL != nil {
+		bodyVars = append(bodyVars, findVariables(request.GraphQL.Query)...)
+		bodyVars = append(bodyVars, findVariables(request.GraphQL.OperationName)...)
+		variables, _ := request.GraphQL.Variables.Render(models.JSONScalar)
+		bodyVars = append(bodyVars, findVariables(variables)...)
+	}
+
+	for _, key := range sortedKeys(request.FormBody) {
+		for _, value := range request.FormBody[key] {
+			bodyVars = append(bodyVars, findVariables(value)...)
+		}
+	}
+
+	for _, field := range request.Multipart {
+		bodyVars = append(bodyVars, findVariables(field.Value)...)
+		bodyVars = append(bodyVars, findVariables(field.File)...)
+	}
 
 	if len(bodyVars) > 0 {
 		fmt.Println(styles.PrimaryText.Render("Body"))
@@ -308,34 +613,27 @@ func (a *App) buildRequest(ctx context.Context, request models.HttpRequestTempla
 	request.Sanitize()
 
 	// Prepare request URL
-	replacedPath, err := replaceVariables(request.Path, vars)
+	reqURL, err := a.requestURL(request, vars)
 	if err != nil {
 		return nil, err
 	}
 
-	host := a.HTTPTemplate.Config.Host
-	if a.HTTPTemplate.Config.Port != 0 {
-		host = fmt.Sprintf("%s:%d", host, a.HTTPTemplate.Config.Port)
-	}
-
-	reqURL := url.URL{
-		Host:   host,
-		Scheme: a.HTTPTemplate.Config.Scheme,
-		Path:   replacedPath,
+	if request.WebSocket != nil {
+		reqURL, _ = webSocketURL(reqURL)
 	}
 
-	reqURL.Scheme = a.HTTPTemplate.Config.Scheme
-
-	// Prepare query params
+	// Prepare query params, params defined on the request override the defaults from config.
 	query := reqURL.Query()
 
-	for key, value := range request.Query {
-		replacedParam, err := replaceVariables(value, vars)
-		if err != nil {
-			return nil, err
-		}
+	for _, params := range []map[string]string{a.HTTPTemplate.Config.Query, request.Query} {
+		for key, value := range params {
+			replacedParam, err := a.replaceVariables(value, vars)
+			if err != nil {
+				return nil, err
+			}
 
-		query.Set(key, replacedParam)
+			query.Set(key, replacedParam)
+		}
 	}
 
 	reqURL.RawQuery = query.Encode()
@@ -345,21 +643,84 @@ func (a *App) buildRequest(ctx context.Context, request models.HttpRequestTempla
 	bodyContentType := ""
 
 	if request.Body != "" {
-		replacedBody, err := replaceVariables(request.Body, vars)
+		replacedBody, err := a.replaceVariables(request.Body, vars)
 		if err != nil {
 			return nil, err
 		}
 		body = replacedBody
 		request.Body = replacedBody
 		bodyContentType = "text/plain"
-	} else if request.JsonBody != "" {
-		replacedJsonBody, err := replaceVariables(request.JsonBody, vars)
+	} else if !request.JsonBody.IsZero() {
+		replacedJsonBody, err := a.renderJSONBody(request.JsonBody, vars)
 		if err != nil {
 			return nil, err
 		}
 		body = replacedJsonBody
-		request.JsonBody = replacedJsonBody
+		request.JsonBody = models.JSONBody{Text: replacedJsonBody}
 		bodyContentType = "application/json"
+	} else if request.GraphQL != nil {
+		graphQL, graphQLBody, err := a.renderGraphQL(*request.GraphQL, vars)
+		if err != nil {
+			return nil, err
+		}
+		body = graphQLBody
+		request.GraphQL = graphQL
+		// The body is logged, and exported, as the json it is posted as
+		request.JsonBody = models.JSONBody{Text: graphQLBody}
+		bodyContentType = "application/json"
+	} else if request.XmlBody != "" {
+		replacedXmlBody, err := a.replaceVariables(request.XmlBody, vars)
+		if err != nil {
+			return nil, err
+		}
+		body = replacedXmlBody
+		request.XmlBody = replacedXmlBody
+		bodyContentType = "application/xml"
+	} else if len(request.FormBody) > 0 {
+		form := url.Values{}
+		replacedFormBody := make(models.FormBody, len(request.FormBody))
+
+		for _, key := range sortedKeys(request.FormBody) {
+			for _, value := range request.FormBody[key] {
+				replacedValue, err := a.replaceVariables(value, vars)
+				if err != nil {
+					return nil, err
+				}
+
+				form.Add(key, replacedValue)
+				replacedFormBody[key] = append(replacedFormBody[key], replacedValue)
+			}
+		}
+
+		body = form.Encode()
+		request.FormBody = replacedFormBody
+		bodyContentType = "application/x-www-form-urlencoded"
+	}
+
+	// Files are streamed when they are not templates
+	streamBodyFile := false
+
+	if request.BodyFile != nil {
+		bodyFile, err := a.resolveBodyFile(request, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if bodyFile.Template {
+			content, err := os.ReadFile(bodyFile.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			body, err = a.replaceVariables(string(content), vars)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		streamBodyFile = !bodyFile.Template
+		request.BodyFile = bodyFile
+		bodyContentType = fileContentType(bodyFile.Path)
 	}
 
 	httpReq, err := http.NewRequest(request.Method, reqURL.String(), strings.NewReader(body))
@@ -367,17 +728,59 @@ func (a *App) buildRequest(ctx context.Context, request models.HttpRequestTempla
 		return nil, err
 	}
 
+	if streamBodyFile {
+		httpReq.Body, httpReq.GetBody, httpReq.ContentLength, err = fileBody(request.BodyFile.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(request.Multipart) > 0 {
+		fields, err := a.replaceMultipartVariables(request, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		multipartBody, contentType, contentLength, err := multipartBody(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		// The form is streamed, it can't be read again
+		httpReq.Body = multipartBody
+		httpReq.GetBody = nil
+		httpReq.ContentLength = contentLength
+
+		request.Multipart = fields
+		bodyContentType = contentType
+	}
+
 	if bodyContentType != "" {
 		httpReq.Header.Add("Content-Type", bodyContentType)
 	}
 
-	for key, value := range request.Headers {
-		replacedValue, err := replaceVariables(value, vars)
+	if request.SaveTo != nil {
+		request.SaveTo, err = a.resolveSaveTo(request, vars)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		httpReq.Header.Set(key, replacedValue)
+	request.Socket, err = a.resolveSocket(request, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers defined on the request override the defaults from config.
+	for _, headers := range []map[string]string{a.HTTPTemplate.Config.Headers, request.Headers} {
+		for key, value := range headers {
+			replacedValue, err := a.replaceVariables(value, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			httpReq.Header.Set(key, replacedValue)
+		}
 	}
 
 	return &models.HttpRequest{
@@ -387,67 +790,185 @@ func (a *App) buildRequest(ctx context.Context, request models.HttpRequestTempla
 	}, nil
 }
 
-func replaceVariables(s string, vars variable.Variables) (string, error) {
-	matches := inputRegex.FindAllStringSubmatch(s, -1)
-	if len(matches) == 0 {
-		return s, nil
+// resolveBodyFile replaces the variables in the path of the body file of the request,
+// the path is resolved relative to the file the request is defined in.
+func (a *App) resolveBodyFile(request models.HttpRequestTemplate, vars variable.Variables) (*models.BodyFile, error) {
+	replacedPath, err := a.replaceVariables(request.BodyFile.Path, vars)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, match := range matches {
-		key := match[1]
-		inputType := "" // Input type is the type to be enforced for the input
-		if len(match) > 2 {
-			inputType = match[2]
+	return &models.BodyFile{
+		Path:     resolvePath(replacedPath, request.Dir),
+		Template: request.BodyFile.Template,
+	}, nil
+}
+
+// replaceMultipartVariables replaces the variables in the multipart fields of the request,
+// paths of the files are resolved relative to the file the request is defined in.
+func (a *App) replaceMultipartVariables(request models.HttpRequestTemplate, vars variable.Variables) ([]models.MultipartField, error) {
+	fields := make([]models.MultipartField, 0, len(request.Multipart))
+
+	for _, field := range request.Multipart {
+		for _, value := range []*string{&field.Name, &field.Value, &field.File, &field.Filename, &field.ContentType} {
+			replacedValue, err := a.replaceVariables(*value, vars)
+			if err != nil {
+				return nil, err
+			}
+			*value = replacedValue
 		}
 
-		// Check if variable is present in vars
-		if variable, ok := vars.Get(key); ok {
-			s = strings.ReplaceAll(s, match[0], fmt.Sprintf("%v", variable.Value))
-			continue
+		if field.File != "" {
+			field = resolveMultipartFile(field, request.Dir)
 		}
 
-		// Variable not present in vars, prompt user for input
-		label := styles.PrimaryText.Render(fmt.Sprintf("`%s`", key))
-		if inputType != "" {
-			label += styles.SecondaryText.Render(fmt.Sprintf(" (%s)", inputType))
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// requestURL resolves the URL of the request, either from the absolute url of the request
+// or from the path and the host of the service the request is sent to.
+func (a *App) requestURL(request models.HttpRequestTemplate, vars variable.Variables) (*url.URL, error) {
+	if request.URL != "" {
+		replacedURL, err := a.replaceVariables(request.URL, vars)
+		if err != nil {
+			return nil, err
 		}
 
-		input, err := getUserInput(label)
+		return url.Parse(replacedURL)
+	}
+
+	service, ok := a.HTTPTemplate.Config.Service(request.Service)
+	if !ok {
+		return nil, fmt.Errorf("service '%s' is not defined", request.Service)
+	}
+
+	replacedPath, err := a.replaceVariables(request.Path, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	replacedBasePath, err := a.replaceVariables(service.BasePath, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &url.URL{
+		Host:   service.Address(),
+		Scheme: service.Scheme,
+		Path:   joinURLPath(replacedBasePath, replacedPath),
+	}, nil
+}
+
+// displayURL returns the URL of the request for display purposes, variables are not replaced.
+func (a *App) displayURL(request models.HttpRequestTemplate) string {
+	if request.URL != "" {
+		return request.URL
+	}
+
+	service, _ := a.HTTPTemplate.Config.Service(request.Service)
+
+	return service.Scheme + "://" + service.Address() + joinURLPath(service.BasePath, request.Path)
+}
+
+// joinURLPath joins the request path to the base path.
+func joinURLPath(basePath, requestPath string) string {
+	if basePath == "" {
+		return requestPath
+	}
+
+	return strings.TrimSuffix(basePath, "/") + "/" + strings.TrimPrefix(requestPath, "/")
+}
+
+// replaceVariables replaces the placeholders in s with their values.
+func (a *App) replaceVariables(s string, vars variable.Variables) (string, error) {
+	// Quotes of values typed at the prompt, for variables without a type, are escaped
+	prompted := make(map[string]bool)
+
+	return placeholder.Replace(s, func(p placeholder.Placeholder) (string, error) {
+		v, isVariable := p.Variable(defined(vars))
+		if _, ok := vars.Get(v.Name); isVariable && !ok && v.Type == "" {
+			prompted[v.Name] = true
+		}
+
+		value, err := a.evalPlaceholder(p, vars)
 		if err != nil {
 			return "", err
 		}
 
-		switch inputType {
-		case "int":
-			_, err := strconv.Atoi(input)
-			if err != nil {
-				return "", fmt.Errorf("input for `%s` must be of type int", key)
-			}
-		case "float":
-			_, err := strconv.ParseFloat(input, 64)
-			if err != nil {
-				return "", fmt.Errorf("input for `%s` must be of type float", key)
-			}
-		case "bool":
-			if input != "true" && input != "false" {
-				return "", fmt.Errorf("input for `%s` must be of type bool", key)
+		text := placeholder.String(value)
+		if isVariable && prompted[v.Name] {
+			text = strings.ReplaceAll(text, `"`, `\"`)
+		}
+
+		return text, nil
+	})
+}
+
+// evalPlaceholder evaluates the placeholder, asking for the variables that are not set.
+func (a *App) evalPlaceholder(p placeholder.Placeholder, vars variable.Variables) (any, error) {
+	functions := a.Functions
+	if functions == nil {
+		functions = placeholder.Functions
+	}
+
+	// Functions given the values standing in for exports of pre-requests that are not
+	// executed would return values that mean nothing, the placeholder is kept instead.
+	if _, isVariable := p.Variable(defined(vars)); !isVariable {
+		for _, v := range p.Variables(defined(vars)) {
+			if value, ok := vars.Get(v.Name); ok && value.Source == variable.SourcePlaceholder {
+				return p.Text, nil
 			}
-		case "string":
-		case "":
-			// Esacpe quotes
-			input = strings.ReplaceAll(input, `"`, `\"`)
 		}
+	}
 
-		s = strings.ReplaceAll(s, match[0], input)
+	return p.Eval(func(name string, inputType string) (any, error) {
+		return a.variableValue(name, inputType, vars)
+	}, defined(vars), functions)
+}
 
-		// Add variable to vars
-		vars.Add(variable.Variable{
-			Value:  input,
-			Source: variable.SourceInput,
-		})
+// defined reports whether the variable is set, variables that are set take precedence
+// over the functions with the same name.
+func defined(vars variable.Variables) placeholder.Defined {
+	return func(name string) bool {
+		_, ok := vars.Get(name)
+		return ok
 	}
+}
 
-	return s, nil
+// promptInput prompts user for the value of the variable and makes sure
+// it is of the input type.
+func promptInput(key string, inputType string) (string, error) {
+	label := styles.PrimaryText.Render(fmt.Sprintf("`%s`", key))
+	if inputType != "" {
+		label += styles.SecondaryText.Render(fmt.Sprintf(" (%s)", inputType))
+	}
+
+	input, err := getUserInput(label)
+	if err != nil {
+		return "", err
+	}
+
+	switch inputType {
+	case "int":
+		_, err := strconv.Atoi(input)
+		if err != nil {
+			return "", fmt.Errorf("input for `%s` must be of type int", key)
+		}
+	case "float":
+		_, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return "", fmt.Errorf("input for `%s` must be of type float", key)
+		}
+	case "bool":
+		if input != "true" && input != "false" {
+			return "", fmt.Errorf("input for `%s` must be of type bool", key)
+		}
+	}
+
+	return input, nil
 }
 
 // getUserInput prompts user for input and returns it.
@@ -466,3 +987,13 @@ func getUserInput(label string) (string, error) {
 
 	return string(line), err
 }
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
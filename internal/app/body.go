@@ -0,0 +1,195 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// quoteEscaper escapes quoted values of the Content-Disposition header, same as mime/multipart does.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartBody returns the multipart form made of the fields along with its content type
+// and length. The form is streamed, files are read while the request is sent instead of
+// being loaded in memory.
+func multipartBody(fields []models.MultipartField) (io.ReadCloser, string, int64, error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	// Length of the form is the length of the form without the content
+	// of the files, plus the size of the files.
+	counter := &countingWriter{}
+	err := writeMultipart(counter, boundary, fields, func(path string) (io.ReadCloser, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		counter.n += info.Size()
+
+		return io.NopCloser(strings.NewReader("")), nil
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	body := &pipeReader{
+		write: func(w io.Writer) error {
+			return writeMultipart(w, boundary, fields, func(path string) (io.ReadCloser, error) {
+				return os.Open(path)
+			})
+		},
+	}
+
+	return body, "multipart/form-data; boundary=" + boundary, counter.n, nil
+}
+
+// writeMultipart writes the multipart form to w, the content of the files is read using open.
+func writeMultipart(w io.Writer, boundary string, fields []models.MultipartField, open func(path string) (io.ReadCloser, error)) error {
+	form := multipart.NewWriter(w)
+
+	err := form.SetBoundary(boundary)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if field.File == "" {
+			err := form.WriteField(field.Name, field.Value)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(field.Name), quoteEscaper.Replace(field.Filename)))
+		header.Set("Content-Type", field.ContentType)
+
+		part, err := form.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		file, err := open(field.File)
+		if err != nil {
+			return fmt.Errorf("multipart field '%s': %w", field.Name, err)
+		}
+
+		_, err = io.Copy(part, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return form.Close()
+}
+
+// resolveMultipartFile fills the defaults of a file field, its path is resolved relative to dir.
+func resolveMultipartFile(field models.MultipartField, dir string) models.MultipartField {
+	field.File = resolvePath(field.File, dir)
+
+	if field.Filename == "" {
+		field.Filename = filepath.Base(field.File)
+	}
+
+	if field.ContentType == "" {
+		field.ContentType = fileContentType(field.File)
+	}
+
+	return field
+}
+
+// fileBody returns a body streaming the file along with its length. GetBody of the request
+// is set to the returned function, the file is opened again every time it is called.
+func fileBody(path string) (io.ReadCloser, func() (io.ReadCloser, error), int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	getBody := func() (io.ReadCloser, error) {
+		return &pipeReader{
+			write: func(w io.Writer) error {
+				file, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				_, err = io.Copy(w, file)
+				return err
+			},
+		}, nil
+	}
+
+	body, _ := getBody()
+
+	return body, getBody, info.Size(), nil
+}
+
+// resolvePath resolves the path relative to dir, which is the directory of the request file.
+func resolvePath(path string, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// fileContentType guesses the content type of the file from its extension.
+func fileContentType(path string) string {
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+
+	return contentType
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// pipeReader reads what write writes to a pipe. Writing starts on the first read, so
+// nothing is left writing when a request is built but never sent.
+type pipeReader struct {
+	once   sync.Once
+	reader *io.PipeReader
+	write  func(w io.Writer) error
+}
+
+func (r *pipeReader) start() {
+	r.once.Do(func() {
+		reader, writer := io.Pipe()
+		r.reader = reader
+
+		go func() {
+			writer.CloseWithError(r.write(writer))
+		}()
+	})
+}
+
+func (r *pipeReader) Read(p []byte) (int, error) {
+	r.start()
+	return r.reader.Read(p)
+}
+
+func (r *pipeReader) Close() error {
+	r.start()
+	return r.reader.Close()
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// progressWidth is the number of characters of the progress bar.
+const progressWidth = 30
+
+// resolveSaveTo replaces the variables in the path of the file the response of the
+// request is saved to, the path is resolved relative to the file the request is defined in.
+func (a *App) resolveSaveTo(request models.HttpRequestTemplate, vars variable.Variables) (*models.SaveTo, error) {
+	replacedPath, err := a.replaceVariables(request.SaveTo.Path, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SaveTo{
+		Path:   resolvePath(replacedPath, request.Dir),
+		Resume: request.SaveTo.Resume,
+	}, nil
+}
+
+// resumeOffset returns the size of the file already downloaded, when the download is
+// resumed, and asks the server for the rest of the body using a Range request.
+func resumeOffset(saveTo models.SaveTo, request *http.Request) (int64, error) {
+	if !saveTo.Resume {
+		return 0, nil
+	}
+
+	info, err := os.Stat(saveTo.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if info.Size() > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", info.Size()))
+	}
+
+	return info.Size(), nil
+}
+
+// saveResponse writes the body of the response to the file, appending to it when the
+// server sends the rest of a resumed download. It returns the size of the file. The file
+// is left as it is when the response is an error.
+func saveResponse(response *http.Response, path string, offset int64) (int64, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	total := response.ContentLength
+
+	switch {
+	case offset > 0 && response.StatusCode == http.StatusPartialContent:
+		if !strings.HasPrefix(response.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return 0, fmt.Errorf("server resumed the download with an unexpected range '%s'", response.Header.Get("Content-Range"))
+		}
+
+		flags = os.O_WRONLY | os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+	case offset > 0 && response.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// There is nothing left to download
+		return offset, nil
+	case response.StatusCode < 200 || response.StatusCode >= 300:
+		return 0, fmt.Errorf("not saving to %s, server responded with %s", path, response.Status)
+	case offset > 0 && response.StatusCode != http.StatusOK:
+		return 0, fmt.Errorf("not resuming %s, server responded with %s instead of the rest of the file", path, response.Status)
+	default:
+		// Servers not supporting Range requests send the whole body
+		offset = 0
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+
+	if isTerminal(os.Stderr) {
+		bar := &progress{out: os.Stderr, written: offset, total: total}
+		defer bar.done()
+
+		w = io.MultiWriter(file, bar)
+	}
+
+	written, err := io.Copy(w, response.Body)
+	if err != nil {
+		return offset + written, fmt.Errorf("saving to %s: %w", path, err)
+	}
+
+	return offset + written, file.Close()
+}
+
+// progress draws a progress bar of a download, total is -1 when it is not known.
+type progress struct {
+	out      io.Writer
+	written  int64
+	total    int64
+	lastDraw time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+
+	// Drawing on every write slows down the download
+	if time.Since(p.lastDraw) >= 100*time.Millisecond {
+		p.draw()
+	}
+
+	return len(b), nil
+}
+
+func (p *progress) draw() {
+	p.lastDraw = time.Now()
+
+	if p.total <= 0 {
+		fmt.Fprintf(p.out, "\r%s\033[K", formatSize(p.written))
+		return
+	}
+
+	ratio := min(float64(p.written)/float64(p.total), 1)
+	filled := int(ratio * progressWidth)
+
+	fmt.Fprintf(p.out, "\r[%s%s] %3.0f%% %s / %s\033[K",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled),
+		ratio*100, formatSize(p.written), formatSize(p.total))
+}
+
+// done draws the final state of the bar and moves to the next line.
+func (p *progress) done() {
+	p.draw()
+	fmt.Fprintln(p.out)
+}
+
+// formatSize formats the number of bytes using binary units, for example 1.5 MB.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+
+	return fmt.Sprintf("%.1f TB", value)
+}
+
+// isTerminal reports whether the file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
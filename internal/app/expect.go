@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"github.com/yalp/jsonpath"
+)
+
+// matcher matches websocket messages, and events of streams, against an expect.
+type matcher struct {
+	expect  models.Expect
+	equals  string
+	pattern *regexp.Regexp
+}
+
+// newMatcher returns a matcher for the expect, replacing the variables in equals.
+func (a *App) newMatcher(expect models.Expect, vars variable.Variables) (*matcher, error) {
+	equals, err := a.replaceVariables(expect.Equals, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &matcher{expect: expect, equals: equals}
+
+	if expect.Regex != "" {
+		m.pattern, err = regexp.Compile(expect.Regex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// match reports whether the regex matches the message, or the JSONPath selects a
+// value in it, equal to equals when it is set.
+func (m *matcher) match(message string) bool {
+	if m.pattern != nil {
+		return m.pattern.MatchString(message)
+	}
+
+	var parsed any
+	if err := json.Unmarshal([]byte(message), &parsed); err != nil {
+		return false
+	}
+
+	value, err := jsonpath.Read(parsed, m.expect.JSON)
+	if err != nil {
+		return false
+	}
+
+	return m.equals == "" || fmt.Sprint(value) == m.equals
+}
+
+func (m *matcher) String() string {
+	switch {
+	case m.pattern != nil:
+		return fmt.Sprintf("regex '%s'", m.expect.Regex)
+	case m.equals != "":
+		return fmt.Sprintf("'%s' = '%s'", m.expect.JSON, m.equals)
+	}
+
+	return fmt.Sprintf("'%s'", m.expect.JSON)
+}
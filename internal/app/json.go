@@ -0,0 +1,225 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurleensethi/yurl/internal/placeholder"
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// renderJSONBody converts the json body to json, replacing the variables in it. In a body
+// written as yaml, a string made of just a variable is replaced by the value of the variable
+// keeping its type: {{ id:int }} becomes a number and an exported object stays an object.
+func (a *App) renderJSONBody(body models.JSONBody, vars variable.Variables) (string, error) {
+	if body.Node == nil {
+		return a.replaceVariables(body.Text, vars)
+	}
+
+	return body.Render(func(node *yaml.Node) (string, error) {
+		if node.ShortTag() != "!!str" {
+			return models.JSONScalar(node)
+		}
+
+		if p, ok := placeholder.Whole(node.Value); ok {
+			return a.jsonPlaceholder(p, vars)
+		}
+
+		text, err := a.interpolate(node.Value, vars)
+		if err != nil {
+			return "", err
+		}
+
+		return models.MarshalJSON(text)
+	})
+}
+
+// jsonVariable returns the value of the variable as json, converted to the input type.
+func (a *App) jsonVariable(key string, inputType string, vars variable.Variables) (string, error) {
+	value, err := a.variableValue(key, inputType, vars)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := value.(string)
+	if !ok || inputType == "" || inputType == "string" {
+		return models.MarshalJSON(value)
+	}
+
+	var converted any
+	switch inputType {
+	case "int":
+		converted, err = strconv.Atoi(s)
+	case "float":
+		converted, err = strconv.ParseFloat(s, 64)
+	case "bool":
+		converted, err = strconv.ParseBool(s)
+	}
+
+	if err != nil {
+		// Values supplied by Input, like the placeholders used by codegen, are written as they are.
+		if a.Input != nil {
+			return s, nil
+		}
+
+		return "", fmt.Errorf("variable `%s` must be of type %s", key, inputType)
+	}
+
+	return models.MarshalJSON(converted)
+}
+
+// jsonPlaceholder returns the value of the placeholder as json. Variables are converted
+// to their type, values of functions keep theirs: {{ randomInt 1 10 }} becomes a number.
+func (a *App) jsonPlaceholder(p placeholder.Placeholder, vars variable.Variables) (string, error) {
+	if v, ok := p.Variable(defined(vars)); ok {
+		return a.jsonVariable(v.Name, v.Type, vars)
+	}
+
+	value, err := a.evalPlaceholder(p, vars)
+	if err != nil {
+		return "", err
+	}
+
+	if t, ok := value.(time.Time); ok {
+		return models.MarshalJSON(placeholder.String(t))
+	}
+
+	return models.MarshalJSON(value)
+}
+
+// interpolate replaces the placeholders in s with their values, values that are
+// not strings, like exported objects, are written as json.
+func (a *App) interpolate(s string, vars variable.Variables) (string, error) {
+	return placeholder.Replace(s, func(p placeholder.Placeholder) (string, error) {
+		value, err := a.evalPlaceholder(p, vars)
+		if err != nil {
+			return "", err
+		}
+
+		return placeholder.String(value), nil
+	})
+}
+
+// variableValue returns the value of the variable, asking for it when it is not set.
+func (a *App) variableValue(key string, inputType string, vars variable.Variables) (any, error) {
+	if v, ok := vars.Get(key); ok {
+		return v.Value, nil
+	}
+
+	getInput := a.Input
+	if getInput == nil {
+		getInput = promptInput
+	}
+
+	input, err := getInput(key, inputType)
+	if err != nil {
+		return nil, err
+	}
+
+	vars.Add(variable.Variable{
+		Key:    key,
+		Value:  input,
+		Source: variable.SourceInput,
+	})
+
+	return input, nil
+}
+
+// renderGraphQL returns the graphql operation with its variables replaced, along with the
+// json body it is posted as.
+func (a *App) renderGraphQL(graphQL models.GraphQL, vars variable.Variables) (*models.GraphQL, string, error) {
+	query, err := a.replaceVariables(graphQL.Query, vars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	operationName, err := a.replaceVariables(graphQL.OperationName, vars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	variables, err := a.renderJSONBody(graphQL.Variables, vars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The body is written by hand, rather than encoded, as the variables may hold
+	// the placeholders used by codegen, which are not valid json.
+	var body strings.Builder
+
+	body.WriteString("{\n  \"query\": ")
+	if err := writeJSONString(&body, query); err != nil {
+		return nil, "", err
+	}
+
+	if operationName != "" {
+		body.WriteString(",\n  \"operationName\": ")
+		if err := writeJSONString(&body, operationName); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if strings.TrimSpace(variables) != "" {
+		body.WriteString(",\n  \"variables\": " + strings.ReplaceAll(strings.TrimSpace(variables), "\n", "\n  "))
+	}
+
+	body.WriteString("\n}")
+
+	return &models.GraphQL{
+		Query:         query,
+		OperationName: operationName,
+		Variables:     models.JSONBody{Text: variables},
+	}, body.String(), nil
+}
+
+func writeJSONString(b *strings.Builder, s string) error {
+	content, err := models.MarshalJSON(s)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString(content)
+
+	return nil
+}
+
+// graphQLData returns the data of the graphql response, or the body as it is when it
+// has no data.
+func graphQLData(body []byte) []byte {
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil || response.Data == nil {
+		return body
+	}
+
+	return response.Data
+}
+
+// graphQLErrors returns an error listing the errors of the graphql response, graphql
+// servers report errors in the body of responses that are otherwise successful.
+func graphQLErrors(body []byte) error {
+	var response struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	// Responses that are not json are left to the exports to report
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Errors) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(response.Errors))
+	for i, e := range response.Errors {
+		messages[i] = e.Message
+	}
+
+	return fmt.Errorf("%w: %s", ErrGraphQL, strings.Join(messages, "; "))
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// resolveSocket returns the path of the unix domain socket the request is sent over, empty
+// when it is sent over tcp. The socket of the request takes precedence over the one of the
+// service. The socket of the request is resolved relative to the file the request is
+// defined in, the one of the config relative to the root file that holds the config.
+func (a *App) resolveSocket(request models.HttpRequestTemplate, vars variable.Variables) (string, error) {
+	socket, dir := request.Socket, request.Dir
+
+	// Absolute urls are not sent to a service
+	if socket == "" && request.URL == "" {
+		service, _ := a.HTTPTemplate.Config.Service(request.Service)
+		socket, dir = service.Socket, a.HTTPTemplate.Dir
+	}
+
+	if socket == "" {
+		return "", nil
+	}
+
+	replacedSocket, err := a.replaceVariables(socket, vars)
+	if err != nil {
+		return "", err
+	}
+
+	return resolvePath(replacedSocket, dir), nil
+}
+
+// newHTTPClient returns the client the request is sent with, requests sent over a
+// unix domain socket connect to the socket whatever the host of the url is.
+func newHTTPClient(socket string) *http.Client {
+	if socket == "" {
+		return &http.Client{}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialSocket(ctx, socket)
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// dialSocket connects to the unix domain socket at the path.
+func dialSocket(ctx context.Context, socket string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", socket)
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// streamMediaTypes are the content types of responses read as a stream without the stream option.
+var streamMediaTypes = []string{
+	"text/event-stream",
+	"application/x-ndjson",
+	"application/ndjson",
+	"application/jsonl",
+	"application/x-jsonlines",
+}
+
+// maxEventSize is the size of the largest line of a streamed response.
+const maxEventSize = 1024 * 1024
+
+// isStreamed reports whether the response is read as a stream, either because the
+// request enables it or because of the content type of the response.
+func isStreamed(stream *models.Stream, header http.Header) bool {
+	if stream != nil {
+		return !stream.Disabled
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	for _, streamMediaType := range streamMediaTypes {
+		if mediaType == streamMediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readStream reads the events of the response, until the stream ends or one of the
+// options stops it, printing each event as it arrives when print is set. It returns
+// the body read along with the event exports are read from.
+func (a *App) readStream(response *http.Response, stream models.Stream, vars variable.Variables, print bool) ([]byte, string, error) {
+	var until *matcher
+	if stream.Until != nil {
+		var err error
+		until, err = a.newMatcher(*stream.Until, vars)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	timeout, err := stream.TimeoutDuration()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Closing the body stops the read once the timeout is reached
+	var timedOut atomic.Bool
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			response.Body.Close()
+		})
+		defer timer.Stop()
+	}
+
+	var body bytes.Buffer
+	var first, last, matched string
+	count := 0
+	found := false
+
+	onEvent := func(event string) bool {
+		if print {
+			fmt.Println(event)
+		}
+
+		if count == 0 {
+			first = event
+		}
+		last = event
+		count++
+
+		if until != nil && until.match(event) {
+			matched = event
+			found = true
+			return false
+		}
+
+		return stream.MaxEvents == 0 || count < stream.MaxEvents
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	reader := io.TeeReader(response.Body, &body)
+
+	if mediaType == "text/event-stream" {
+		err = readServerSentEvents(reader, onEvent)
+	} else {
+		err = readLines(reader, onEvent)
+	}
+
+	switch {
+	case timedOut.Load():
+		if until != nil && !found {
+			return body.Bytes(), "", fmt.Errorf("no event matched %s within %s", until, timeout)
+		}
+	case err != nil:
+		return body.Bytes(), "", err
+	case until != nil && !found:
+		return body.Bytes(), "", fmt.Errorf("stream ended before an event matched %s", until)
+	}
+
+	switch stream.EventSource() {
+	case models.ExportFromFirst:
+		return body.Bytes(), first, nil
+	case models.ExportFromUntil:
+		return body.Bytes(), matched, nil
+	}
+
+	return body.Bytes(), last, nil
+}
+
+// readLines calls onEvent with every line that isn't empty, until it returns false.
+func readLines(r io.Reader, onEvent func(event string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxEventSize)
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if !onEvent(line) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// readServerSentEvents calls onEvent with the data of every server-sent event, until it
+// returns false. Events end with a blank line, comments and other fields are ignored.
+func readServerSentEvents(r io.Reader, onEvent func(event string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxEventSize)
+
+	var data []string
+	hasData := false
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if line == "" {
+			if hasData && !onEvent(strings.Join(data, "\n")) {
+				return nil
+			}
+
+			data = data[:0]
+			hasData = false
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		if field == "data" {
+			data = append(data, strings.TrimPrefix(value, " "))
+			hasData = true
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gurleensethi/yurl/internal/logger"
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"golang.org/x/net/websocket"
+)
+
+// executeWebSocket upgrades the request to a websocket and runs its steps. The messages
+// received become the body of the response and the exports of the steps its exports.
+func (a *App) executeWebSocket(ctx context.Context, httpRequest *models.HttpRequest, webSocket models.WebSocket, vars variable.Variables, verbose bool) (*models.HttpResponse, error) {
+	timeout, err := webSocket.TimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	wsURL, origin := webSocketURL(httpRequest.RawRequest.URL)
+
+	config, err := websocket.NewConfig(wsURL.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	config.Header = httpRequest.RawRequest.Header.Clone()
+
+	startedAt := time.Now()
+
+	conn, err := dialWebSocket(ctx, config, httpRequest.Template.Socket)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	httpResponse := &models.HttpResponse{
+		Request:   httpRequest,
+		Exports:   make(map[string]any),
+		StartedAt: startedAt,
+	}
+
+	var received []string
+
+	// Exports of the steps are only seen by the steps that follow, requests see them
+	// through the exports of the response, like the exports of any other request.
+	stepVars := variable.NewVariables()
+	stepVars.Merge(vars)
+
+	for i, step := range webSocket.Steps {
+		if step.Expect == nil {
+			message, err := a.renderJSONBody(step.Send, stepVars)
+			if err != nil {
+				return nil, err
+			}
+
+			if verbose {
+				logger.LogWebSocketMessage(ctx, true, message)
+			}
+
+			err = websocket.Message.Send(conn, message)
+			if err != nil {
+				return nil, fmt.Errorf("websocket step %d: %w", i, err)
+			}
+
+			httpResponse.Messages = append(httpResponse.Messages, models.WebSocketMessage{Sent: true, Time: time.Now(), Data: message})
+
+			continue
+		}
+
+		message, err := a.expectMessage(conn, *step.Expect, stepVars, timeout, func(message string) {
+			received = append(received, message)
+			httpResponse.Messages = append(httpResponse.Messages, models.WebSocketMessage{Time: time.Now(), Data: message})
+
+			if verbose {
+				logger.LogWebSocketMessage(ctx, false, message)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("websocket step %d: %w", i, err)
+		}
+
+		for _, name := range sortedKeys(step.Exports) {
+			value, err := exportValue([]byte(message), step.Exports[name])
+			if err != nil {
+				return nil, fmt.Errorf("%w: export '%s': %w", ErrParsingExports, name, err)
+			}
+
+			httpResponse.Exports[name] = value
+
+			stepVars.Add(variable.Variable{
+				Key:    name,
+				Value:  value,
+				Source: variable.SourceExports,
+			})
+		}
+	}
+
+	httpResponse.RawBody = []byte(strings.Join(received, "\n"))
+	httpResponse.Duration = time.Since(startedAt)
+
+	if verbose {
+		logger.LogExports(ctx, httpResponse.Exports)
+	}
+
+	return httpResponse, nil
+}
+
+// dialWebSocket opens the websocket connection, over the unix domain socket when it is set.
+func dialWebSocket(ctx context.Context, config *websocket.Config, socket string) (*websocket.Conn, error) {
+	if socket == "" {
+		return config.DialContext(ctx)
+	}
+
+	conn, err := dialSocket(ctx, socket)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Location.Scheme == "wss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: config.Location.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	wsConn, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return wsConn, nil
+}
+
+// expectMessage reads messages until one matches expect, every message read is passed to onMessage.
+func (a *App) expectMessage(conn *websocket.Conn, expect models.Expect, vars variable.Variables, timeout time.Duration, onMessage func(message string)) (string, error) {
+	m, err := a.newMatcher(expect, vars)
+	if err != nil {
+		return "", err
+	}
+
+	err = conn.SetReadDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		var message string
+
+		err := websocket.Message.Receive(conn, &message)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return "", fmt.Errorf("no message matched %s within %s", m, timeout)
+			}
+
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("connection closed before a message matched %s", m)
+			}
+
+			return "", err
+		}
+
+		onMessage(message)
+
+		if m.match(message) {
+			return message, nil
+		}
+	}
+}
+
+// webSocketURL returns the websocket url of the request, http becomes ws and https
+// becomes wss, along with the origin sent when upgrading the connection. Websocket
+// urls are returned as they are.
+func webSocketURL(requestURL *url.URL) (*url.URL, *url.URL) {
+	wsURL := *requestURL
+	origin := url.URL{Scheme: "http", Host: requestURL.Host}
+
+	switch requestURL.Scheme {
+	case "http":
+		wsURL.Scheme = "ws"
+	case "https", "wss":
+		wsURL.Scheme = "wss"
+		origin.Scheme = "https"
+	}
+
+	return &wsURL, &origin
+}
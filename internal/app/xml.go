@@ -0,0 +1,37 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// xpathValue evaluates the XPath against the xml body. Expressions selecting nodes return
+// the text of the first node, other expressions, like count(//item), return their result.
+func xpathValue(body []byte, expression string) (any, error) {
+	expr, err := xpath.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath '%s': %w", expression, err)
+	}
+
+	doc, err := xmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	result := expr.Evaluate(xmlquery.CreateXPathNavigator(doc))
+
+	iterator, ok := result.(*xpath.NodeIterator)
+	if !ok {
+		return result, nil
+	}
+
+	if !iterator.MoveNext() {
+		return nil, errors.New("xpath matched no nodes")
+	}
+
+	return iterator.Current().Value(), nil
+}
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/gurleensethi/yurl/internal/app"
+	"github.com/gurleensethi/yurl/internal/har"
 	"github.com/gurleensethi/yurl/internal/variable"
 	"github.com/gurleensethi/yurl/pkg/models"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -36,9 +38,16 @@ Use variables
 Use a variable file
 
   yurl -var-file=local.vars <request name>
+
+Use an environment
+
+  yurl -env staging <request name>
 `
 
 	ErrParsingExports = errors.New("error parsing exports")
+
+	// standaloneCommands are commands that run without loading the requests file upfront.
+	standaloneCommands = []string{"init", "validate", "schema", "import"}
 )
 
 const (
@@ -48,6 +57,13 @@ const (
 	FlagFile          = "file"
 	FlagListVariables = "list-variables"
 	FlagPath          = "path"
+	FlagEnv           = "env"
+	FlagName          = "name"
+	FlagNoPre         = "no-pre"
+	FlagHAR           = "har"
+	FlagHost          = "host"
+	FlagOutput        = "output"
+	FlagResume        = "resume"
 )
 
 type CliApp struct {
@@ -90,6 +106,24 @@ func (a *CliApp) Build() *cli.App {
 				Usage:   "list all variables in the request",
 				Aliases: []string{"lv", "list-vars"},
 			},
+			&cli.StringFlag{
+				Name:    FlagEnv,
+				Usage:   "name of the environment (from config.environments) to use",
+				Aliases: []string{"e"},
+			},
+			&cli.StringFlag{
+				Name:  FlagHAR,
+				Usage: "write every request sent, including pre-requests, along with its response to a HAR file",
+			},
+			&cli.StringFlag{
+				Name:    FlagOutput,
+				Usage:   "save the body of the response to the given file, instead of printing it",
+				Aliases: []string{"o"},
+			},
+			&cli.BoolFlag{
+				Name:  FlagResume,
+				Usage: "resume the download of a partially saved file, see --output",
+			},
 		},
 		Commands: []*cli.Command{
 			{
@@ -103,6 +137,117 @@ func (a *CliApp) Build() *cli.App {
 				},
 				Action: InitConfigFile,
 			},
+			{
+				Name:   "validate",
+				Usage:  "report all the problems in the requests (yaml) file",
+				Action: ValidateHTTPYamlFile,
+			},
+			{
+				Name:   "schema",
+				Usage:  "print the JSON Schema of the requests (yaml) file",
+				Action: PrintJSONSchema,
+			},
+			{
+				Name:  "import",
+				Usage: "import requests into the requests (yaml) file",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "curl",
+						Usage:     "import a curl command line as a request",
+						ArgsUsage: "'<curl command>'",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  FlagName,
+								Usage: "name of the imported request",
+							},
+						},
+						Action: ImportCurl,
+					},
+					{
+						Name:      "openapi",
+						Usage:     "import every operation of an OpenAPI 3 document as a request",
+						ArgsUsage: "<spec.yaml>",
+						Action:    ImportOpenAPI,
+					},
+					{
+						Name:      "postman",
+						Usage:     "import every request of a Postman collection (v2.1)",
+						ArgsUsage: "<collection.json>",
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  FlagEnv,
+								Usage: "Postman environment to import as an environment",
+							},
+						},
+						Action: ImportPostman,
+					},
+					{
+						Name:      "har",
+						Usage:     "import the requests captured in a HAR file",
+						ArgsUsage: "<file.har>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  FlagHost,
+								Usage: "only import requests sent to the host",
+							},
+						},
+						Action: ImportHAR,
+					},
+				},
+			},
+			{
+				Name:  "export",
+				Usage: "export a request from the requests (yaml) file",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "curl",
+						Usage:     "print the request as a curl command line",
+						ArgsUsage: "<request name>",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  FlagNoPre,
+								Usage: "don't execute pre-requests, use placeholders for the variables they export",
+							},
+						},
+						Action: a.ExportCurl,
+					},
+					{
+						Name:  "postman",
+						Usage: "print the requests as a Postman collection (v2.1)",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  FlagName,
+								Usage: "name of the collection, defaults to the name of the requests file",
+							},
+						},
+						Action: a.ExportPostman,
+					},
+				},
+			},
+			{
+				Name:  "codegen",
+				Usage: "generate code sending a request along with its pre-requests",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "go",
+						Usage:     "generate a Go program using net/http",
+						ArgsUsage: "<request name>",
+						Action:    a.Codegen("go"),
+					},
+					{
+						Name:      "python",
+						Usage:     "generate a Python 3 script using urllib",
+						ArgsUsage: "<request name>",
+						Action:    a.Codegen("python"),
+					},
+					{
+						Name:      "js",
+						Usage:     "generate a JavaScript (Node.js) script using fetch",
+						ArgsUsage: "<request name>",
+						Action:    a.Codegen("js"),
+					},
+				},
+			},
 			{
 				Name:  "version",
 				Usage: "print the version of yurl",
@@ -203,9 +348,9 @@ func (a *CliApp) Build() *cli.App {
 			// },
 		},
 		Before: func(cliCtx *cli.Context) error {
-			// Don't try to load http.yaml file if command is an
-			// initialization command.
-			if cliCtx.NArg() >= 1 && cliCtx.Args().First() == "init" {
+			// Don't try to load http.yaml file if command doesn't need it
+			// or loads it on its own.
+			if cliCtx.NArg() >= 1 && slices.Contains(standaloneCommands, cliCtx.Args().First()) {
 				return nil
 			}
 
@@ -219,6 +364,38 @@ func (a *CliApp) Build() *cli.App {
 				return err
 			}
 
+			err = httpTemplate.ResolveExtends()
+			if err != nil {
+				return err
+			}
+
+			variables := variable.NewVariables()
+
+			for key, value := range httpTemplate.Variables {
+				variables.Add(variable.Variable{
+					Key:    key,
+					Value:  value,
+					Source: variable.SourceRequestFile,
+				})
+			}
+
+			// Environment overrides the config and its variables take precedence
+			// only over the variables from the request file.
+			if envName := cliCtx.String(FlagEnv); envName != "" {
+				env, err := httpTemplate.Config.UseEnvironment(envName)
+				if err != nil {
+					return err
+				}
+
+				for key, value := range env.Variables {
+					variables.Add(variable.Variable{
+						Key:    key,
+						Value:  value,
+						Source: variable.SourceEnvironment,
+					})
+				}
+			}
+
 			httpTemplate.Sanitize()
 
 			err = httpTemplate.Validate()
@@ -233,7 +410,9 @@ func (a *CliApp) Build() *cli.App {
 				return err
 			}
 
-			a.app = app.New(*httpTemplate, fileVariables)
+			variables.Merge(fileVariables)
+
+			a.app = app.New(*httpTemplate, variables)
 
 			return nil
 		},
@@ -243,53 +422,94 @@ func (a *CliApp) Build() *cli.App {
 				return nil
 			}
 
-			cliVariables := variable.NewVariables()
-
-			// Parse variables from command line
-			for _, v := range cliCtx.StringSlice(FlagVariable) {
-				parsedVariable, err := variable.ParseStringWithSource(v, variable.SourceCLI)
-				if err != nil && !errors.As(err, &variable.ErrInvalidFormat{}) {
-					return err
-				}
-
-				cliVariables.Add(parsedVariable)
+			cliVariables, err := parseCliVariables(cliCtx)
+			if err != nil {
+				return err
 			}
 
 			requestName := cliCtx.Args().First()
 
-			return a.app.ExecuteRequest(cliCtx.Context, requestName, app.ExecuteRequestOpts{
+			harPath := cliCtx.String(FlagHAR)
+			if harPath != "" {
+				a.app.Recorder = har.NewRecorder(Version)
+			}
+
+			err = a.app.ExecuteRequest(cliCtx.Context, requestName, app.ExecuteRequestOpts{
 				Verbose:   cliCtx.Bool(FlagVerbose),
 				Variables: cliVariables,
+				Output:    cliCtx.String(FlagOutput),
+				Resume:    cliCtx.Bool(FlagResume),
 			})
+
+			// Requests sent before a failure are written as well, to help debugging it.
+			if harPath != "" {
+				harErr := a.app.Recorder.WriteFile(harPath)
+				if harErr != nil && err == nil {
+					err = harErr
+				}
+			}
+
+			return err
 		},
 	}
 }
 
+// parseHTTPYamlFile parses the request file along with all the files it includes
+// into a single template. Config is always taken from the root file.
 func (a *CliApp) parseHTTPYamlFile(_ context.Context, filePath string) (*models.HttpTemplate, error) {
-	file, err := os.Open(filePath)
+	files, err := loadHTTPYamlFiles(filePath, "", nil)
 	if err != nil {
-		if err == os.ErrNotExist {
-			return nil, fmt.Errorf("no request file found at %s", filePath)
-		}
 		return nil, err
 	}
 
-	var template models.HttpTemplate
+	template := files[0].Template
+	template.Dir = filepath.Dir(files[0].Path)
+	template.Variables = make(map[string]string)
+	template.Requests = make(map[string]models.HttpRequestTemplate)
 
-	err = yaml.NewDecoder(file).Decode(&template)
-	if err != nil {
-		return nil, err
-	}
+	for _, file := range files {
+		if file.DecodeErr != nil {
+			return nil, file.DecodeErr
+		}
 
-	// Set name for each request
-	for name, req := range template.Requests {
-		req.Name = name
-		template.Requests[name] = req
+		// Variables of the including file take precedence over included ones.
+		for key, value := range file.Template.Variables {
+			if _, ok := template.Variables[key]; !ok {
+				template.Variables[key] = value
+			}
+		}
+
+		for name, req := range file.Template.Requests {
+			if _, ok := template.Requests[name]; ok {
+				return nil, fmt.Errorf("%s: request '%s' is already defined", file.Path, name)
+			}
+
+			template.Requests[name] = req
+		}
 	}
 
 	return &template, nil
 }
 
+// parseCliVariables parses the variables passed on the command line.
+func parseCliVariables(c *cli.Context) (variable.Variables, error) {
+	variables := variable.NewVariables()
+
+	for _, v := range c.StringSlice(FlagVariable) {
+		parsedVariable, err := variable.ParseStringWithSource(v, variable.SourceCLI)
+		if err != nil {
+			if errors.As(err, &variable.ErrInvalidFormat{}) {
+				continue
+			}
+			return nil, err
+		}
+
+		variables.Add(parsedVariable)
+	}
+
+	return variables, nil
+}
+
 func (a *CliApp) parseVariablesFromFiles(_ context.Context, filePaths []string) (variable.Variables, error) {
 	variables := variable.NewVariables()
 
@@ -314,10 +534,15 @@ func (a *CliApp) parseVariablesFromFiles(_ context.Context, filePaths []string)
 
 			line := string(lineBytes)
 
-			parsedVariable, err := variable.ParseStringWithSource(line, variable.SourceInput)
-			if err != nil && !errors.As(err, &variable.ErrInvalidFormat{}) {
-				variables.Add(parsedVariable)
+			parsedVariable, err := variable.ParseStringWithSource(line, variable.SourceVarFile)
+			if err != nil {
+				if errors.As(err, &variable.ErrInvalidFormat{}) {
+					continue
+				}
+				return nil, err
 			}
+
+			variables.Add(parsedVariable)
 		}
 	}
 
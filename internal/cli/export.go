@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/app"
+	"github.com/gurleensethi/yurl/internal/codegen"
+	"github.com/gurleensethi/yurl/internal/curl"
+	"github.com/gurleensethi/yurl/internal/postman"
+	"github.com/urfave/cli/v2"
+)
+
+// ExportCurl prints the request as a curl command line. Pre-requests are executed
+// to resolve the variables they export, unless --no-pre is used.
+func (a *CliApp) ExportCurl(c *cli.Context) error {
+	args, noPreRequests := extractBoolFlag(c.Args().Slice(), FlagNoPre)
+	noPreRequests = noPreRequests || c.Bool(FlagNoPre)
+
+	if len(args) == 0 {
+		return errors.New("request name is required")
+	}
+
+	cliVariables, err := parseCliVariables(c)
+	if err != nil {
+		return err
+	}
+
+	request, err := a.app.BuildRequest(c.Context, args[0], app.BuildRequestOpts{
+		Variables:     cliVariables,
+		NoPreRequests: noPreRequests,
+	})
+	if err != nil {
+		return err
+	}
+
+	command, err := curl.Format(request)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(command)
+
+	return nil
+}
+
+// ExportPostman prints the requests file as a Postman collection (v2.1).
+func (a *CliApp) ExportPostman(c *cli.Context) error {
+	args, name := extractFlag(c.Args().Slice(), FlagName)
+	if name == "" {
+		name = c.String(FlagName)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	if name == "" {
+		filePath := c.String(FlagFile)
+		if filePath == "" {
+			filePath = DefaultHTTPYamlFile
+		}
+		name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+
+	collection, err := postman.Export(a.app.HTTPTemplate, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(collection))
+
+	return nil
+}
+
+// Codegen returns the action printing code, in the language, that sends the request
+// along with its pre-requests.
+func (a *CliApp) Codegen(language string) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if c.Args().Len() == 0 {
+			return errors.New("request name is required")
+		}
+
+		cliVariables, err := parseCliVariables(c)
+		if err != nil {
+			return err
+		}
+
+		// Variables without a value, including the ones exported by pre-requests,
+		// become variables of the generated code.
+		a.app.Input = func(key string, _ string) (string, error) {
+			return codegen.Marker(key), nil
+		}
+
+		// Functions are called by the generated code, every time it runs, rather than
+		// once while generating it.
+		calls := &codegen.Calls{}
+		a.app.Functions = calls.Functions()
+
+		requestName := c.Args().First()
+
+		requests, err := a.app.BuildRequestChain(c.Context, requestName, cliVariables)
+		if err != nil {
+			return err
+		}
+
+		program, err := codegen.NewProgram(requestName, requests, calls)
+		if err != nil {
+			return err
+		}
+
+		code, err := codegen.Generate(language, program)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(code)
+
+		return nil
+	}
+}
+
+// extractBoolFlag removes the flag from args, reporting whether it was present.
+// Flags placed after the arguments are not parsed by the cli, for example:
+// yurl export curl Login --no-pre
+func extractBoolFlag(args []string, name string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+
+	for _, arg := range args {
+		if arg == "--"+name || arg == "-"+name {
+			found = true
+			continue
+		}
+
+		rest = append(rest, arg)
+	}
+
+	return rest, found
+}
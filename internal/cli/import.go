@@ -0,0 +1,507 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/curl"
+	"github.com/gurleensethi/yurl/internal/har"
+	"github.com/gurleensethi/yurl/internal/openapi"
+	"github.com/gurleensethi/yurl/internal/postman"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportCurl imports a curl command line as a new request in the requests file.
+//
+// The command can be passed as a single argument, or already split by the shell
+// after `--`, for example: yurl import curl --name GetTodo -- curl https://...
+func ImportCurl(c *cli.Context) error {
+	args, name := extractFlag(c.Args().Slice(), FlagName)
+	if name == "" {
+		name = c.String(FlagName)
+	}
+
+	if len(args) == 0 {
+		return errors.New("curl command is required")
+	}
+
+	var (
+		request models.HttpRequestTemplate
+		err     error
+	)
+
+	if len(args) == 1 {
+		request, err = curl.Parse(args[0])
+	} else {
+		request, err = curl.ParseArgs(args)
+	}
+	if err != nil {
+		return err
+	}
+
+	request.Name = name
+	if request.Name == "" {
+		request.Name = models.RouteName(request.Method, request.URL)
+	}
+
+	return importRequests(c, models.HttpTemplate{}, []models.HttpRequestTemplate{request})
+}
+
+// ImportOpenAPI imports every operation of an OpenAPI 3.x document as a request.
+func ImportOpenAPI(c *cli.Context) error {
+	if c.Args().Len() == 0 {
+		return errors.New("path of the OpenAPI document is required")
+	}
+
+	file, err := os.Open(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	template, err := openapi.Parse(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.Args().First(), err)
+	}
+
+	template.Config.Sanitize()
+
+	names := make([]string, 0, len(template.Requests))
+	for name := range template.Requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Requests are imported with absolute urls so that ones not pointing to
+	// the config of an existing requests file keep their host.
+	requests := make([]models.HttpRequestTemplate, 0, len(names))
+	for _, name := range names {
+		request := template.Requests[name]
+
+		service, _ := template.Config.Service("")
+		request.URL = service.Scheme + "://" + service.Address() + path.Join("/", service.BasePath, request.Path)
+		request.Path = ""
+
+		requests = append(requests, request)
+	}
+
+	return importRequests(c, *template, requests)
+}
+
+// ImportPostman imports every request of a Postman collection (v2.1). Postman
+// environments passed with --env become environments of the config.
+func ImportPostman(c *cli.Context) error {
+	args, envPaths := extractFlagValues(c.Args().Slice(), FlagEnv)
+	envPaths = append(c.StringSlice(FlagEnv), envPaths...)
+
+	if len(args) == 0 {
+		return errors.New("path of the Postman collection is required")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	template, err := postman.Parse(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	for _, envPath := range envPaths {
+		envFile, err := os.Open(envPath)
+		if err != nil {
+			return err
+		}
+
+		name, env, err := postman.ParseEnvironment(envFile)
+		envFile.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", envPath, err)
+		}
+
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(envPath), filepath.Ext(envPath))
+		}
+
+		if template.Config.Environments == nil {
+			template.Config.Environments = make(map[string]models.Environment)
+		}
+		template.Config.Environments[name] = env
+	}
+
+	names := make([]string, 0, len(template.Requests))
+	for name := range template.Requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	requests := make([]models.HttpRequestTemplate, 0, len(names))
+	for _, name := range names {
+		requests = append(requests, template.Requests[name])
+	}
+
+	return importRequests(c, *template, requests)
+}
+
+// ImportHAR imports the requests captured in a HAR file, for example exported from
+// the network tab of the browser devtools.
+func ImportHAR(c *cli.Context) error {
+	args, host := extractFlag(c.Args().Slice(), FlagHost)
+	if host == "" {
+		host = c.String(FlagHost)
+	}
+
+	if len(args) == 0 {
+		return errors.New("path of the HAR file is required")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	requests, err := har.Parse(file, host)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	if len(requests) == 0 {
+		return fmt.Errorf("no requests found in %s", args[0])
+	}
+
+	return importRequests(c, models.HttpTemplate{}, requests)
+}
+
+// importRequests adds the requests to the requests file, keeping its comments and ordering.
+// If the file doesn't exist it is created with config and variables of the template,
+// when the template has no host the config is made from the url of the first request.
+// If it exists, variables and environments of the template it doesn't have are added.
+//
+// Absolute urls pointing to the host of the config, or one of its services, are
+// replaced with a path.
+func importRequests(c *cli.Context, template models.HttpTemplate, requests []models.HttpRequestTemplate) error {
+	filePath := c.String(FlagFile)
+	if filePath == "" {
+		filePath = DefaultHTTPYamlFile
+	}
+
+	if isHTTPFile(filePath) {
+		return fmt.Errorf("can't import requests into %s, only yaml request files are supported", filePath)
+	}
+
+	var document yaml.Node
+
+	content, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = yaml.Unmarshal(content, &document)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	// Missing or empty file
+	if document.Kind == 0 {
+		if template.Config.Host == "" && len(requests) > 0 {
+			urlConfig := configFromURL(requests[0].URL)
+			template.Config.Host = urlConfig.Host
+			template.Config.Port = urlConfig.Port
+			template.Config.Scheme = urlConfig.Scheme
+		}
+
+		document, err = newTemplateDocument(template)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = addTemplateNodes(document.Content[0], template)
+		if err != nil {
+			return err
+		}
+	}
+
+	var existing models.HttpTemplate
+
+	err = document.Decode(&existing)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	config := existing.Config
+	config.Sanitize()
+
+	root := document.Content[0]
+
+	requestsNode := childNode(root, "requests", false)
+	if requestsNode == nil || requestsNode.Kind != yaml.MappingNode {
+		requestsNode = &yaml.Node{Kind: yaml.MappingNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "requests"}, requestsNode)
+	}
+
+	for _, request := range requests {
+		if childNode(requestsNode, request.Name, false) != nil {
+			return fmt.Errorf("request '%s' already exists in %s", request.Name, filePath)
+		}
+
+		useConfigHost(&request, config)
+
+		var requestNode yaml.Node
+		err := requestNode.Encode(request)
+		if err != nil {
+			return err
+		}
+
+		requestsNode.Content = append(requestsNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: request.Name}, &requestNode)
+	}
+
+	var out bytes.Buffer
+
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+
+	err = encoder.Encode(&document)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, out.Bytes(), 0o644)
+	if err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		fmt.Printf("request imported: %s\n", request.Name)
+	}
+
+	return nil
+}
+
+// newTemplateDocument creates the document of a new requests file with config
+// and variables of the template.
+func newTemplateDocument(template models.HttpTemplate) (yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	var configNode yaml.Node
+	err := configNode.Encode(template.Config)
+	if err != nil {
+		return yaml.Node{}, err
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "config"}, &configNode)
+
+	if len(template.Variables) > 0 {
+		var variablesNode yaml.Node
+		err := variablesNode.Encode(template.Variables)
+		if err != nil {
+			return yaml.Node{}, err
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "variables"}, &variablesNode)
+	}
+
+	return yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, nil
+}
+
+// addTemplateNodes adds the variables, and the environments, of the template
+// to the root of an existing document, unless they are already defined.
+func addTemplateNodes(root *yaml.Node, template models.HttpTemplate) error {
+	if len(template.Variables) > 0 {
+		err := addMissingKeys(root, []string{"variables"}, template.Variables)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(template.Config.Environments) > 0 {
+		err := addMissingKeys(root, []string{"config", "environments"}, template.Config.Environments)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addMissingKeys adds the entries of values, that don't exist yet, to the mapping at the path,
+// creating the mappings along the path when needed.
+func addMissingKeys[V any](root *yaml.Node, path []string, values map[string]V) error {
+	node := root
+	for _, key := range path {
+		next := childNode(node, key, false)
+		switch {
+		case next == nil:
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, next)
+		case next.Kind != yaml.MappingNode:
+			// Keys without a value, like `variables:`
+			*next = yaml.Node{Kind: yaml.MappingNode}
+		}
+		node = next
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if childNode(node, key, false) != nil {
+			continue
+		}
+
+		var valueNode yaml.Node
+		err := valueNode.Encode(values[key])
+		if err != nil {
+			return err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+	}
+
+	return nil
+}
+
+// configFromURL makes a config pointing to the host of the url.
+func configFromURL(rawURL string) models.Config {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return models.Config{}
+	}
+
+	config := models.Config{
+		Host:   u.Hostname(),
+		Scheme: u.Scheme,
+	}
+
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		config.Port = port
+	}
+
+	return config
+}
+
+// useConfigHost replaces the absolute url of the request with a path, when the url
+// points to the host of the config or one of its services.
+func useConfigHost(request *models.HttpRequestTemplate, config models.Config) {
+	if request.URL == "" {
+		return
+	}
+
+	u, err := url.Parse(request.URL)
+	if err != nil {
+		return
+	}
+
+	serviceNames := make([]string, 0, len(config.Services))
+	for name := range config.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	for _, name := range append([]string{""}, serviceNames...) {
+		service, _ := config.Service(name)
+		if service.Scheme == "" {
+			service.Scheme = config.Scheme
+		}
+
+		if !pointsTo(u, service) {
+			continue
+		}
+
+		path := u.Path
+		if basePath := strings.TrimSuffix(service.BasePath, "/"); basePath != "" {
+			if path != basePath && !strings.HasPrefix(path, basePath+"/") {
+				continue
+			}
+			path = strings.TrimPrefix(path, basePath)
+		}
+
+		if path == "" {
+			path = "/"
+		}
+
+		request.URL = ""
+		request.Service = name
+		request.Path = path
+
+		return
+	}
+}
+
+// pointsTo reports whether the url points to the host of the service.
+func pointsTo(u *url.URL, service models.Service) bool {
+	if u.Hostname() != service.Host || u.Scheme != service.Scheme {
+		return false
+	}
+
+	return effectivePort(u.Scheme, u.Port()) == effectivePort(service.Scheme, strconv.Itoa(service.Port))
+}
+
+// effectivePort returns the port, or the default port of the scheme when there is none.
+func effectivePort(scheme string, port string) string {
+	if port != "" && port != "0" {
+		return port
+	}
+
+	if scheme == "https" {
+		return "443"
+	}
+
+	return "80"
+}
+
+// extractFlag removes the flag, and its value, from args. Flags placed after the arguments
+// are not parsed by the cli, for example: yurl import curl '<command>' --name GetTodo
+func extractFlag(args []string, name string) ([]string, string) {
+	rest, values := extractFlagValues(args, name)
+	if len(values) == 0 {
+		return rest, ""
+	}
+
+	return rest, values[len(values)-1]
+}
+
+// extractFlagValues removes every occurrence of the flag, and its value, from args.
+func extractFlagValues(args []string, name string) ([]string, []string) {
+	rest := make([]string, 0, len(args))
+	var values []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--"+name || arg == "-"+name {
+			if i+1 < len(args) {
+				values = append(values, args[i+1])
+				i++
+			}
+			continue
+		}
+
+		if v, ok := strings.CutPrefix(arg, "--"+name+"="); ok {
+			values = append(values, v)
+			continue
+		}
+
+		rest = append(rest, arg)
+	}
+
+	return rest, values
+}
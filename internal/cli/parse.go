@@ -1 +1,167 @@
 package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/httpfile"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// httpYamlFile is a single request file loaded while parsing a http template,
+// either the root file or one of the files it includes.
+type httpYamlFile struct {
+	Path      string
+	Namespace string
+	Node      *yaml.Node
+	Template  models.HttpTemplate
+
+	// DecodeErr is the error from decoding the template from the node. Template is
+	// still partially decoded, which allows reporting all the problems in a file.
+	DecodeErr error
+}
+
+// loadHTTPYamlFiles loads the request file at filePath and recursively all the files
+// it includes. Requests of each file are named under the namespace of that file.
+//
+// includeChain contains the absolute paths of the files that led to this file
+// being included and is used to detect include cycles.
+func loadHTTPYamlFiles(filePath string, namespace string, includeChain []string) ([]httpYamlFile, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(includeChain, absPath) {
+		return nil, fmt.Errorf("cycle detected in includes: %s", strings.Join(append(includeChain, absPath), " -> "))
+	}
+	includeChain = append(includeChain, absPath)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no request file found at %s", filePath)
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	// Files in the .http format are converted to a template, they have no includes.
+	if isHTTPFile(filePath) {
+		template, err := httpfile.Parse(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+
+		namespaceRequests(template, namespace)
+		setRequestsDir(template, filepath.Dir(filePath))
+
+		return []httpYamlFile{{
+			Path:      filePath,
+			Namespace: namespace,
+			Node:      &yaml.Node{},
+			Template:  *template,
+		}}, nil
+	}
+
+	var node yaml.Node
+
+	err = yaml.NewDecoder(file).Decode(&node)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	var template models.HttpTemplate
+
+	decodeErr := node.Decode(&template)
+	if decodeErr != nil {
+		decodeErr = fmt.Errorf("%s: %w", filePath, decodeErr)
+	}
+
+	namespaceRequests(&template, namespace)
+	setRequestsDir(&template, filepath.Dir(filePath))
+
+	files := []httpYamlFile{{
+		Path:      filePath,
+		Namespace: namespace,
+		Node:      &node,
+		Template:  template,
+		DecodeErr: decodeErr,
+	}}
+
+	for _, include := range template.Include {
+		if include.Path == "" {
+			return nil, fmt.Errorf("%s: include path is required", filePath)
+		}
+
+		includePath := include.Path
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(filePath), includePath)
+		}
+
+		includeNamespace := include.Namespace
+		if includeNamespace == "" {
+			includeNamespace = strings.TrimSuffix(filepath.Base(includePath), filepath.Ext(includePath))
+		}
+
+		includedFiles, err := loadHTTPYamlFiles(includePath, models.QualifiedName(namespace, includeNamespace), includeChain)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, includedFiles...)
+	}
+
+	return files, nil
+}
+
+// isHTTPFile reports whether the file is in the .http format, used by
+// VS Code REST Client and JetBrains HTTP Client.
+func isHTTPFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".http" || ext == ".rest"
+}
+
+// namespaceRequests names all the requests of the template under the namespace.
+//
+// Pre-requests and extends that name a request defined in the same file are qualified
+// with the namespace as well, any other name is kept as is so that requests can refer
+// to requests from other namespaces, for example `auth.Login`.
+func namespaceRequests(template *models.HttpTemplate, namespace string) {
+	requests := make(map[string]models.HttpRequestTemplate, len(template.Requests))
+
+	for name, req := range template.Requests {
+		req.Name = models.QualifiedName(namespace, name)
+
+		preRequests := make([]models.PreRequest, 0, len(req.PreRequests))
+		for _, preRequest := range req.PreRequests {
+			if _, ok := template.Requests[preRequest.Name]; ok {
+				preRequest.Name = models.QualifiedName(namespace, preRequest.Name)
+			}
+			preRequests = append(preRequests, preRequest)
+		}
+		req.PreRequests = preRequests
+
+		if _, ok := template.Requests[req.Extends]; ok {
+			req.Extends = models.QualifiedName(namespace, req.Extends)
+		}
+
+		requests[req.Name] = req
+	}
+
+	template.Requests = requests
+}
+
+// setRequestsDir sets the directory of the file the requests are defined in.
+func setRequestsDir(template *models.HttpTemplate, dir string) {
+	for name, req := range template.Requests {
+		req.Dir = dir
+		template.Requests[name] = req
+	}
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// PrintJSONSchema prints the JSON Schema of the http template, which can be used
+// by editors for completion and validation of request files.
+func PrintJSONSchema(c *cli.Context) error {
+	generator := schemaGenerator{defs: make(map[string]any)}
+	ref := generator.schema(reflect.TypeOf(models.HttpTemplate{}))
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "yurl http template",
+		"$ref":    ref["$ref"],
+		"$defs":   generator.defs,
+	}
+
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+
+	return nil
+}
+
+// schemaGenerator generates JSON Schema from the models. Each struct
+// is added to defs once and referred to by its name.
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+// fieldSchemas are schemas of fields that can't be derived from their type, keyed
+// by the name of the struct and the yaml key of the field.
+var fieldSchemas = map[string]func() map[string]any{
+	"Config.scheme":                schemeSchema,
+	"Service.scheme":               schemeSchema,
+	"Environment.scheme":           schemeSchema,
+	"HttpRequestTemplate.method":   methodSchema,
+	"HttpRequestTemplate.jsonBody": jsonBodySchema,
+	"GraphQL.variables":            jsonBodySchema,
+	"WebSocketStep.send":           jsonBodySchema,
+	"Stream.exportFrom":            exportFromSchema,
+}
+
+// scalarSchemas are the scalars types with their own unmarshaling can be written as,
+// keyed by the name of the type. Types that are not listed are written as a string.
+var scalarSchemas = map[string]map[string]any{
+	"Stream": {"type": "boolean"},
+}
+
+func schemeSchema() map[string]any {
+	return map[string]any{"enum": []string{"http", "https"}}
+}
+
+// jsonBodySchema allows the json body to be written as a string, or as yaml.
+func jsonBodySchema() map[string]any {
+	return map[string]any{"type": []string{"string", "object", "array"}}
+}
+
+func exportFromSchema() map[string]any {
+	return map[string]any{"enum": []string{models.ExportFromFirst, models.ExportFromLast, models.ExportFromUntil}}
+}
+
+func methodSchema() map[string]any {
+	methods := make([]string, 0, len(models.Methods)*2)
+	for _, method := range models.Methods {
+		methods = append(methods, method, strings.ToLower(method))
+	}
+
+	return map[string]any{"enum": methods}
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Struct:
+		ref := g.structSchema(t)
+
+		// Types with their own unmarshaling are allowed to be written as a scalar as well.
+		if reflect.PointerTo(t).Implements(yamlUnmarshalerType) {
+			scalar, ok := scalarSchemas[t.Name()]
+			if !ok {
+				scalar = map[string]any{"type": "string"}
+			}
+
+			return map[string]any{"anyOf": []any{scalar, ref}}
+		}
+
+		return ref
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		array := map[string]any{"type": "array", "items": g.schema(t.Elem())}
+
+		if reflect.PointerTo(t).Implements(yamlUnmarshalerType) {
+			return map[string]any{"anyOf": []any{map[string]any{"type": "string"}, array}}
+		}
+
+		return array
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema adds the struct to defs and returns a reference to it.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	name := t.Name()
+	ref := map[string]any{"$ref": "#/$defs/" + name}
+
+	if _, ok := g.defs[name]; ok {
+		return ref
+	}
+
+	// Reserve the name before generating properties so that recursive types terminate.
+	g.defs[name] = nil
+
+	properties := make(map[string]any)
+	for key, field := range yamlFields(t) {
+		if fieldSchema, ok := fieldSchemas[name+"."+key]; ok {
+			properties[key] = fieldSchema()
+			continue
+		}
+
+		properties[key] = g.schema(field.Type)
+	}
+
+	g.defs[name] = map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	return ref
+}
+
+// yamlFields returns the fields of the struct keyed by their yaml key, following
+// the same rules as yaml.v3 does for decoding.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+
+		fields[key] = field
+	}
+
+	return fields
+}
@@ -0,0 +1,365 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var typeErrorLineRegex = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// diagnostic is a problem found in a request file along with its position.
+type diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+
+	// fileIndex is the order in which the file was loaded, used for sorting.
+	fileIndex int
+}
+
+func (d diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+}
+
+// requestNode is the position of a request in the file it is defined in.
+type requestNode struct {
+	fileIndex int
+	file      string
+	key       *yaml.Node
+	value     *yaml.Node
+}
+
+// ValidateHTTPYamlFile reports every problem found in the request file, and the files
+// it includes, with the position of the problem.
+func ValidateHTTPYamlFile(c *cli.Context) error {
+	filePath := c.String(FlagFile)
+	if filePath == "" {
+		filePath = DefaultHTTPYamlFile
+	}
+
+	files, err := loadHTTPYamlFiles(filePath, "", nil)
+	if err != nil {
+		return err
+	}
+
+	var diagnostics []diagnostic
+
+	template := files[0].Template
+	template.Requests = make(map[string]models.HttpRequestTemplate)
+	requestNodes := make(map[string]requestNode)
+
+	for i, file := range files {
+		report := func(node *yaml.Node, format string, a ...any) {
+			diagnostics = append(diagnostics, diagnostic{
+				File:      file.Path,
+				Line:      node.Line,
+				Column:    node.Column,
+				Message:   fmt.Sprintf(format, a...),
+				fileIndex: i,
+			})
+		}
+
+		diagnostics = append(diagnostics, decodeDiagnostics(i, file)...)
+
+		checkKeys(file.Node, reflect.TypeOf(models.HttpTemplate{}), report)
+
+		// Files in the .http format have no nodes, their requests are validated without positions.
+		if isHTTPFile(file.Path) {
+			for name, request := range file.Template.Requests {
+				template.Requests[name] = request
+			}
+			continue
+		}
+
+		requests := findNode(file.Node, "requests")
+		if requests == nil || requests.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for j := 0; j+1 < len(requests.Content); j += 2 {
+			key, value := requests.Content[j], requests.Content[j+1]
+			name := models.QualifiedName(file.Namespace, key.Value)
+
+			if _, ok := requestNodes[name]; ok {
+				report(key, "request '%s' is already defined", name)
+				continue
+			}
+
+			requestNodes[name] = requestNode{fileIndex: i, file: file.Path, key: key, value: value}
+			template.Requests[name] = file.Template.Requests[name]
+		}
+	}
+
+	if envName := c.String(FlagEnv); envName != "" {
+		_, err := template.Config.UseEnvironment(envName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Problems in the extends chains are reported along with the rest of the problems.
+	_ = template.ResolveExtends()
+
+	template.Sanitize()
+
+	for _, problem := range template.Problems() {
+		diagnostics = append(diagnostics, locateProblem(problem, files[0], requestNodes))
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.fileIndex != b.fileIndex {
+			return a.fileIndex < b.fileIndex
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	for _, d := range diagnostics {
+		fmt.Println(d)
+	}
+
+	if len(diagnostics) > 0 {
+		return fmt.Errorf("found %d problem(s)", len(diagnostics))
+	}
+
+	fmt.Printf("no problems found in %s\n", filePath)
+
+	return nil
+}
+
+// decodeDiagnostics converts errors from decoding the template, like a string
+// used in place of a number, to diagnostics.
+func decodeDiagnostics(fileIndex int, file httpYamlFile) []diagnostic {
+	if file.DecodeErr == nil {
+		return nil
+	}
+
+	var typeErr *yaml.TypeError
+	if !errors.As(file.DecodeErr, &typeErr) {
+		return []diagnostic{{File: file.Path, Line: 1, Column: 1, Message: file.DecodeErr.Error(), fileIndex: fileIndex}}
+	}
+
+	diagnostics := make([]diagnostic, 0, len(typeErr.Errors))
+	for _, e := range typeErr.Errors {
+		d := diagnostic{File: file.Path, Line: 1, Column: 1, Message: e, fileIndex: fileIndex}
+
+		if match := typeErrorLineRegex.FindStringSubmatch(e); match != nil {
+			d.Line, _ = strconv.Atoi(match[1])
+			d.Column = lastColumnOnLine(file.Node, d.Line, 1)
+			d.Message = match[2]
+		}
+
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics
+}
+
+// lastColumnOnLine returns the column of the last node starting on the line, which
+// is the value that failed to decode as type errors only report the line.
+func lastColumnOnLine(node *yaml.Node, line int, column int) int {
+	if node.Line == line && node.Column > column {
+		column = node.Column
+	}
+
+	for _, content := range node.Content {
+		column = lastColumnOnLine(content, line, column)
+	}
+
+	return column
+}
+
+// checkKeys reports every key in the node that doesn't exist on the model type.
+func checkKeys(node *yaml.Node, t reflect.Type, report func(node *yaml.Node, format string, a ...any)) {
+	if node.Kind == yaml.DocumentNode {
+		for _, content := range node.Content {
+			checkKeys(content, t, report)
+		}
+		return
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// Json bodies written as yaml can have any keys
+	if t == reflect.TypeOf(models.JSONBody{}) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		// Types with their own unmarshaling may be written as a scalar.
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		fields := yamlFields(t)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			field, ok := fields[key.Value]
+			if !ok {
+				if suggestion := suggestKey(key.Value, fields); suggestion != "" {
+					report(key, "unknown key '%s', did you mean '%s'?", key.Value, suggestion)
+				} else {
+					report(key, "unknown key '%s'", key.Value)
+				}
+				continue
+			}
+
+			checkKeys(value, field.Type, report)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		for i := 1; i < len(node.Content); i += 2 {
+			checkKeys(node.Content[i], t.Elem(), report)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+
+		for _, item := range node.Content {
+			checkKeys(item, t.Elem(), report)
+		}
+	}
+}
+
+// suggestKey returns the known key closest to the unknown key, if any is close enough.
+func suggestKey(unknown string, fields map[string]reflect.StructField) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	unknown = strings.ToLower(unknown)
+
+	for _, key := range keys {
+		lowerKey := strings.ToLower(key)
+		if strings.HasPrefix(lowerKey, unknown) || strings.HasPrefix(unknown, lowerKey) || editDistance(unknown, lowerKey) <= 2 {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// editDistance returns the levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+// locateProblem finds the position of the problem in the files. Problems in requests
+// are located in the file the request is defined in, everything else in the root file.
+func locateProblem(problem models.Problem, root httpYamlFile, requestNodes map[string]requestNode) diagnostic {
+	d := diagnostic{File: root.Path, Line: 1, Column: 1, Message: problem.Message}
+
+	node := root.Node
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	path := problem.Path
+
+	if len(path) >= 2 && path[0] == "requests" {
+		if request, ok := requestNodes[path[1]]; ok {
+			d.File = request.file
+			d.fileIndex = request.fileIndex
+			node = request.key
+			if len(path) > 2 {
+				node = request.value
+			}
+			path = path[2:]
+		}
+	}
+
+	for i, key := range path {
+		next := childNode(node, key, i == len(path)-1)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	if node != nil && node.Line > 0 {
+		d.Line, d.Column = node.Line, node.Column
+	}
+
+	return d
+}
+
+// childNode returns the child of a mapping or sequence node. For the last element
+// of a path the key is returned for mappings and sequences, as it reads better.
+func childNode(node *yaml.Node, key string, last bool) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != key {
+				continue
+			}
+
+			value := node.Content[i+1]
+			if last && slices.Contains([]yaml.Kind{yaml.MappingNode, yaml.SequenceNode}, value.Kind) {
+				return node.Content[i]
+			}
+
+			return value
+		}
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(key)
+		if err == nil && index >= 0 && index < len(node.Content) {
+			return node.Content[index]
+		}
+	}
+
+	return nil
+}
+
+// findNode returns the value of the key in the root mapping of the document.
+func findNode(document *yaml.Node, key string) *yaml.Node {
+	node := document
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	return childNode(node, key, false)
+}
@@ -0,0 +1,422 @@
+// Package codegen generates standalone code sending the same requests as yurl.
+package codegen
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gurleensethi/yurl/internal/jsonpath"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// Languages are the languages code can be generated in.
+var Languages = []string{"go", "python", "js"}
+
+// markerRegex matches the markers of variables, __YURL_id__, and of calls, __YURL_CALL_0__.
+var markerRegex = regexp.MustCompile(`__YURL_(?:CALL_([0-9]+)|([a-zA-Z0-9]+))__`)
+
+// Marker returns the value standing in for the variable while building requests,
+// it is replaced with the variable in the generated code.
+func Marker(name string) string {
+	return "__YURL_" + name + "__"
+}
+
+// Program is the chain of requests to generate code for.
+type Program struct {
+	// Name of the request the code is generated from.
+	Name string
+
+	// Inputs are the variables that have no value, read from environment variables.
+	Inputs []Input
+
+	// Requests in the order they are sent, the last one being the requested one.
+	Requests []Request
+}
+
+type Input struct {
+	Name   string
+	EnvVar string
+}
+
+type Request struct {
+	Name    string
+	Method  string
+	URL     []Part
+	Headers []Header
+	Body    []Part
+	HasBody bool
+
+	// BodyFile is the path of the file sent as the body, instead of Body.
+	BodyFile string
+
+	// Exports are the variables decoded from the response, only the
+	// ones used by later requests, or of the last request, are set.
+	Exports []Export
+}
+
+type Header struct {
+	Name  string
+	Value []Part
+}
+
+type Export struct {
+	Name string
+	Path []any
+}
+
+// Part is a part of a string, either a literal, a variable or a call to a function.
+type Part struct {
+	Literal  string
+	Variable string
+	Call     *Call
+
+	// Escape is how the variable, or the value of the call, is escaped: "path", "query",
+	// "json", inside a json string, or none.
+	Escape string
+}
+
+// NewProgram makes a program from requests built with markers for the variables
+// that have no value, see Marker, and for the calls to functions, see Calls.
+func NewProgram(name string, requests []*models.HttpRequest, calls *Calls) (*Program, error) {
+	program := &Program{Name: name}
+
+	exported := make(map[string]bool)
+	inputs := make(map[string]bool)
+	used := make(map[string]bool)
+
+	for _, httpRequest := range requests {
+		rawRequest := httpRequest.RawRequest
+
+		if len(httpRequest.Template.Multipart) > 0 {
+			return nil, fmt.Errorf("'%s': multipart bodies are not supported", httpRequest.Template.Name)
+		}
+
+		if httpRequest.Template.WebSocket != nil {
+			return nil, fmt.Errorf("'%s': websocket requests are not supported", httpRequest.Template.Name)
+		}
+
+		if httpRequest.Template.Socket != "" {
+			return nil, fmt.Errorf("'%s': unix socket requests are not supported", httpRequest.Template.Name)
+		}
+
+		request := Request{
+			Name:   httpRequest.Template.Name,
+			Method: rawRequest.Method,
+			URL:    urlParts(rawRequest.URL.String(), calls),
+		}
+
+		headerNames := make([]string, 0, len(rawRequest.Header))
+		for headerName := range rawRequest.Header {
+			headerNames = append(headerNames, headerName)
+		}
+		sort.Strings(headerNames)
+
+		for _, headerName := range headerNames {
+			for _, value := range rawRequest.Header[headerName] {
+				request.Headers = append(request.Headers, Header{Name: headerName, Value: parts(value, "", calls)})
+			}
+		}
+
+		// Files that are not templates are read by the generated code
+		body := ""
+		if bodyFile := httpRequest.Template.BodyFile; bodyFile != nil && !bodyFile.Template {
+			request.BodyFile = bodyFile.Path
+		} else {
+			var err error
+			if body, err = readBody(rawRequest); err != nil {
+				return nil, err
+			}
+		}
+
+		if body != "" {
+			// Variables in form bodies are encoded along with the rest of the form
+			contentType, _, _ := strings.Cut(rawRequest.Header.Get("Content-Type"), ";")
+			switch {
+			case contentType == "application/x-www-form-urlencoded":
+				request.Body = parts(body, "query", calls)
+			case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+				request.Body = jsonParts(body, calls)
+			default:
+				request.Body = parts(body, "", calls)
+			}
+			request.HasBody = true
+		}
+
+		// Variables not exported by an earlier request are inputs
+		for _, part := range request.parts() {
+			if part.Variable == "" {
+				continue
+			}
+
+			used[part.Variable] = true
+
+			if !exported[part.Variable] && !inputs[part.Variable] {
+				inputs[part.Variable] = true
+				program.Inputs = append(program.Inputs, Input{Name: part.Variable, EnvVar: envVarName(part.Variable)})
+			}
+		}
+
+		for _, exportName := range sortedKeys(httpRequest.Template.Exports) {
+			if httpRequest.Template.Exports[exportName].XPath != "" {
+				return nil, fmt.Errorf("export '%s' of '%s': xpath exports are not supported", exportName, request.Name)
+			}
+
+			path, err := jsonpath.Segments(httpRequest.Template.Exports[exportName].JSON)
+			if err != nil {
+				return nil, fmt.Errorf("export '%s' of '%s': %w", exportName, request.Name, err)
+			}
+
+			// Exports of graphql requests are read from the data of the response
+			if httpRequest.Template.GraphQL != nil {
+				path = append([]any{"data"}, path...)
+			}
+
+			request.Exports = append(request.Exports, Export{Name: exportName, Path: path})
+			exported[exportName] = true
+		}
+
+		program.Requests = append(program.Requests, request)
+	}
+
+	// Exports that are not used are skipped, unused variables don't compile in Go.
+	for i := range program.Requests[:len(program.Requests)-1] {
+		request := &program.Requests[i]
+
+		var usedExports []Export
+		for _, export := range request.Exports {
+			if used[export.Name] {
+				usedExports = append(usedExports, export)
+			}
+		}
+		request.Exports = usedExports
+	}
+
+	return program, nil
+}
+
+// Generate generates the code of the program in the language.
+func Generate(language string, program *Program) (string, error) {
+	switch language {
+	case "go":
+		return generateGo(program)
+	case "python":
+		return generatePython(program), nil
+	case "js":
+		return generateJS(program), nil
+	}
+
+	return "", fmt.Errorf("language '%s' is not supported, use one of: %s", language, strings.Join(Languages, ", "))
+}
+
+// parts returns the parts of the request, including the arguments of calls.
+func (r Request) parts() []Part {
+	all := append([]Part{}, r.URL...)
+	for _, header := range r.Headers {
+		all = append(all, header.Value...)
+	}
+	all = append(all, r.Body...)
+
+	for i := 0; i < len(all); i++ {
+		if all[i].Call != nil {
+			for _, arg := range all[i].Call.Args {
+				all = append(all, arg...)
+			}
+		}
+	}
+
+	return all
+}
+
+// urlParts splits the url into parts, variables in the path and the query are escaped.
+// Variables before the path, like a variable holding the whole base url, are not.
+func urlParts(rawURL string, calls *Calls) []Part {
+	pathStart := 0
+	if i := strings.Index(rawURL, "://"); i != -1 {
+		pathStart = i + 3
+	}
+	if i := strings.IndexByte(rawURL[pathStart:], '/'); i != -1 {
+		pathStart += i
+	} else {
+		pathStart = len(rawURL)
+	}
+
+	queryStart := strings.IndexByte(rawURL, '?')
+	if queryStart == -1 {
+		queryStart = len(rawURL)
+	}
+
+	var result []Part
+	last := 0
+
+	for _, match := range markerRegex.FindAllStringSubmatchIndex(rawURL, -1) {
+		if match[0] > last {
+			result = append(result, Part{Literal: rawURL[last:match[0]]})
+		}
+
+		part := markerPart(rawURL, match, calls)
+		switch {
+		case match[0] >= queryStart:
+			part.Escape = "query"
+		case match[0] >= pathStart:
+			part.Escape = "path"
+		}
+
+		result = append(result, part)
+		last = match[1]
+	}
+
+	if last < len(rawURL) {
+		result = append(result, Part{Literal: rawURL[last:]})
+	}
+
+	return result
+}
+
+// parts splits s into literals, variables and calls.
+func parts(s string, escape string, calls *Calls) []Part {
+	var result []Part
+	last := 0
+
+	for _, match := range markerRegex.FindAllStringSubmatchIndex(s, -1) {
+		if match[0] > last {
+			result = append(result, Part{Literal: s[last:match[0]]})
+		}
+
+		part := markerPart(s, match, calls)
+		part.Escape = escape
+
+		result = append(result, part)
+		last = match[1]
+	}
+
+	if last < len(s) {
+		result = append(result, Part{Literal: s[last:]})
+	}
+
+	return result
+}
+
+// markerPart returns the variable or the call of the marker matched by markerRegex.
+func markerPart(s string, match []int, calls *Calls) Part {
+	if match[2] != -1 {
+		return Part{Call: calls.call(s[match[2]:match[3]])}
+	}
+
+	return Part{Variable: s[match[4]:match[5]]}
+}
+
+// jsonParts splits the json body into literals, variables and calls. Variables inside json
+// strings are escaped, their quotes, backslashes and newlines would make the json invalid.
+func jsonParts(s string, calls *Calls) []Part {
+	result := parts(s, "", calls)
+	inString := false
+
+	for i, part := range result {
+		if part.Variable != "" || part.Call != nil {
+			if inString {
+				result[i].Escape = "json"
+			}
+			continue
+		}
+
+		for j := 0; j < len(part.Literal); j++ {
+			switch {
+			case inString && part.Literal[j] == '\\':
+				j++
+			case part.Literal[j] == '"':
+				inString = !inString
+			}
+		}
+	}
+
+	return result
+}
+
+// readBody reads the body of the request, leaving the request body readable.
+func readBody(request *http.Request) (string, error) {
+	if request.GetBody == nil {
+		return "", nil
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+
+	return string(content), err
+}
+
+// envVarName converts the variable name to the name of an environment variable: userId becomes USER_ID.
+func envVarName(name string) string {
+	var result strings.Builder
+
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+
+	return result.String()
+}
+
+// identifier converts the name to an identifier that doesn't clash with the reserved
+// names of the language, including the names used by the generated code.
+func identifier(name string, reserved map[string]bool) string {
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "v" + name
+	}
+
+	for reserved[name] {
+		name += "Value"
+	}
+
+	return name
+}
+
+// requestIdentifier converts the request name to the prefix of its identifiers: auth.Login becomes authLogin.
+func requestIdentifier(name string) string {
+	var result strings.Builder
+	upper := false
+
+	for i, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		switch {
+		case i == 0:
+			result.WriteRune(unicode.ToLower(r))
+		case upper:
+			result.WriteRune(unicode.ToUpper(r))
+		default:
+			result.WriteRune(r)
+		}
+		upper = false
+	}
+
+	if result.Len() == 0 || unicode.IsDigit(rune(result.String()[0])) {
+		return "request" + result.String()
+	}
+
+	return result.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,281 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gurleensethi/yurl/internal/placeholder"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+func TestNewProgram(t *testing.T) {
+	calls := &Calls{}
+
+	program, err := NewProgram("GetMe", []*models.HttpRequest{
+		newRequest(t, calls, models.HttpRequestTemplate{
+			Name: "Login",
+			Exports: map[string]models.Export{
+				"token":  {JSON: "$.data.token"},
+				"unused": {JSON: "$.data.id"},
+			},
+		}, "POST", "https://api.example.com/login", map[string]string{"Content-Type": "application/json"}, `{"user": "{{ username }}"}`),
+		newRequest(t, calls, models.HttpRequestTemplate{
+			Name:    "GetMe",
+			Exports: map[string]models.Export{"name": {JSON: "$.items[0].name"}},
+		}, "GET", "https://api.example.com/users/{{ id }}", map[string]string{
+			"Authorization": "Bearer {{ token }}",
+			"X-Signature":   "{{ hmacSha256 secret id }}",
+		}, ""),
+	}, calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantInputs := []Input{
+		{Name: "username", EnvVar: "USERNAME"},
+		{Name: "id", EnvVar: "ID"},
+		{Name: "secret", EnvVar: "SECRET"},
+	}
+	if !reflect.DeepEqual(program.Inputs, wantInputs) {
+		t.Errorf("Inputs = %+v, want %+v", program.Inputs, wantInputs)
+	}
+
+	// Exports not used by later requests are skipped, except the ones of the last request
+	wantExports := [][]Export{
+		{{Name: "token", Path: []any{"data", "token"}}},
+		{{Name: "name", Path: []any{"items", 0, "name"}}},
+	}
+	for i, request := range program.Requests {
+		if !reflect.DeepEqual(request.Exports, wantExports[i]) {
+			t.Errorf("Exports of %s = %+v, want %+v", request.Name, request.Exports, wantExports[i])
+		}
+	}
+
+	wantBody := []Part{
+		{Literal: `{"user": "`},
+		{Variable: "username", Escape: "json"},
+		{Literal: `"}`},
+	}
+	if body := program.Requests[0].Body; !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("Body = %+v, want %+v", body, wantBody)
+	}
+
+	wantURL := []Part{
+		{Literal: "https://api.example.com/users/"},
+		{Variable: "id", Escape: "path"},
+	}
+	if url := program.Requests[1].URL; !reflect.DeepEqual(url, wantURL) {
+		t.Errorf("URL = %+v, want %+v", url, wantURL)
+	}
+}
+
+func TestNewProgramUnsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		template models.HttpRequestTemplate
+		wantErr  string
+	}{
+		{
+			name:     "multipart",
+			template: models.HttpRequestTemplate{Multipart: []models.MultipartField{{Name: "a", Value: "b"}}},
+			wantErr:  "multipart bodies are not supported",
+		},
+		{
+			name:     "websocket",
+			template: models.HttpRequestTemplate{WebSocket: &models.WebSocket{}},
+			wantErr:  "websocket requests are not supported",
+		},
+		{
+			name:     "unix socket",
+			template: models.HttpRequestTemplate{Socket: "/tmp/app.sock"},
+			wantErr:  "unix socket requests are not supported",
+		},
+		{
+			name:     "xpath export",
+			template: models.HttpRequestTemplate{Exports: map[string]models.Export{"id": {XPath: "//id"}}},
+			wantErr:  "xpath exports are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.template.Name = "Request"
+			request := newRequest(t, &Calls{}, tt.template, "GET", "https://example.com", nil, "")
+
+			_, err := NewProgram("Request", []*models.HttpRequest{request}, &Calls{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewProgram() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	calls := &Calls{}
+
+	program, err := NewProgram("GetMe", []*models.HttpRequest{
+		newRequest(t, calls, models.HttpRequestTemplate{
+			Name:    "Login",
+			Exports: map[string]models.Export{"token": {JSON: "$.data.token"}},
+		}, "POST", "https://api.example.com/login", map[string]string{"Content-Type": "application/json"}, `{"user": "{{ username }}", "id": "{{ uuid }}"}`),
+		newRequest(t, calls, models.HttpRequestTemplate{Name: "GetMe"}, "GET", "https://api.example.com/users/{{ id }}?q={{ q }}", map[string]string{
+			"Authorization": "Bearer {{ token }}",
+			"X-Date":        `{{ now | date "2006-01-02" }}`,
+			"X-Auth":        `{{ concat id ":" q | base64 }}`,
+		}, ""),
+	}, calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		language string
+		want     []string
+	}{
+		{
+			language: "go",
+			want: []string{
+				"// Code generated by yurl from the request GetMe.",
+				`username := os.Getenv("USERNAME")`,
+				`loginRequest, err := http.NewRequest("POST", "https://api.example.com/login", strings.NewReader("{\"user\": \""+jsonString(username)+"\", \"id\": \""+jsonString(uuid())+"\"}"))`,
+				`token := text(lookup(loginResponse, "data", "token"))`,
+				`http.NewRequest("GET", "https://api.example.com/users/"+url.PathEscape(id)+"?q="+url.QueryEscape(q), nil)`,
+				`getMeRequest.Header.Add("Authorization", "Bearer "+token)`,
+				`getMeRequest.Header.Add("X-Auth", base64.StdEncoding.EncodeToString([]byte((id + ":" + q))))`,
+				`getMeRequest.Header.Add("X-Date", time.Now().Format("2006-01-02"))`,
+				"func uuid() string {",
+				"func jsonString(value string) string {",
+			},
+		},
+		{
+			language: "python",
+			want: []string{
+				"# Code generated by yurl from the request GetMe.",
+				"import uuid\n",
+				`username = os.environ.get("USERNAME", "")`,
+				`id_ = os.environ.get("ID", "")`,
+				`"{\"user\": \"" + json.dumps(username)[1:-1] + "\", \"id\": \"" + json.dumps(str(uuid.uuid4()))[1:-1] + "\"}",`,
+				`token = text(lookup(login_response, "data", "token"))`,
+				`"https://api.example.com/users/" + urllib.parse.quote(id_, safe="") + "?q=" + urllib.parse.quote_plus(q),`,
+				`"Authorization": "Bearer " + token,`,
+				`"X-Auth": base64.b64encode((id_ + ":" + q).encode()).decode(),`,
+				`"X-Date": date("2006-01-02", datetime.datetime.now().astimezone()),`,
+				"def date(layout, value):",
+				"print(get_me_body)",
+			},
+		},
+		{
+			language: "js",
+			want: []string{
+				"// Code generated by yurl from the request GetMe.",
+				`let username = process.env.USERNAME ?? "";`,
+				`"{\"user\": \"" + JSON.stringify(username).slice(1, -1) + "\", \"id\": \"" + JSON.stringify(require("node:crypto").randomUUID()).slice(1, -1) + "\"}"`,
+				`let token = text(lookup(loginResponse, "data", "token"));`,
+				`"https://api.example.com/users/" + encodeURIComponent(id) + "?q=" + encodeURIComponent(q),`,
+				`"Authorization": "Bearer " + token,`,
+				`"X-Auth": Buffer.from((id + ":" + q)).toString("base64"),`,
+				`"X-Date": formatDate("2006-01-02", new Date()),`,
+				"function formatDate(layout, value) {",
+				"main();",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			code, err := Generate(tt.language, program)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(code, want) {
+					t.Errorf("code doesn't contain %s\n%s", want, code)
+				}
+			}
+
+			if tt.language == "go" {
+				if _, err := parser.ParseFile(token.NewFileSet(), "main.go", code, parser.AllErrors); err != nil {
+					t.Errorf("generated go code doesn't parse: %v", err)
+				}
+			}
+		})
+	}
+
+	if _, err := Generate("ruby", program); err == nil {
+		t.Error("Generate() of an unknown language succeeded")
+	}
+}
+
+func TestCallsCheck(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{input: `{{ now | date layout }}`, wantErr: "the layout must be a string"},
+		{input: `{{ randomInt 5 1 }}`, wantErr: "max 1 is less than min 5"},
+		{input: `{{ randomInt 1 max }}`},
+		{input: `{{ now | date "2006" }}`},
+	}
+
+	for _, tt := range tests {
+		lookup := func(name string, _ string) (any, error) {
+			return Marker(name), nil
+		}
+
+		p, ok := placeholder.Whole(tt.input)
+		if !ok {
+			t.Fatalf("Whole(%q) is not a placeholder", tt.input)
+		}
+
+		_, err := p.Eval(lookup, nil, (&Calls{}).Functions())
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("Eval(%q) error = %v", tt.input, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Eval(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+// newRequest builds the request the way the app does while generating code, variables
+// without a value are replaced with markers and functions with their calls.
+func newRequest(t *testing.T, calls *Calls, template models.HttpRequestTemplate, method, url string, headers map[string]string, body string) *models.HttpRequest {
+	t.Helper()
+
+	request, err := http.NewRequest(method, replace(t, calls, url), strings.NewReader(replace(t, calls, body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body == "" {
+		request.Body = http.NoBody
+	}
+
+	for key, value := range headers {
+		request.Header.Set(key, replace(t, calls, value))
+	}
+
+	return &models.HttpRequest{Template: &template, RawRequest: request}
+}
+
+func replace(t *testing.T, calls *Calls, s string) string {
+	t.Helper()
+
+	lookup := func(name string, _ string) (any, error) {
+		return Marker(name), nil
+	}
+
+	replaced, err := placeholder.Replace(s, func(p placeholder.Placeholder) (string, error) {
+		value, err := p.Eval(lookup, nil, calls.Functions())
+		return placeholder.String(value), err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return replaced
+}
@@ -0,0 +1,503 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/placeholder"
+)
+
+// Call is a call to a function of a placeholder, written as the same call in the code
+// so that it is made every time the code runs.
+type Call struct {
+	Function string
+
+	// Args are the arguments of the function, the value piped to it being the last one.
+	Args [][]Part
+}
+
+// Calls records the calls made to the functions of placeholders while building requests.
+type Calls struct {
+	calls []*Call
+}
+
+// Functions returns functions recording their calls, they return a marker standing in
+// for the call which is replaced with the call in the generated code.
+func (c *Calls) Functions() map[string]placeholder.Function {
+	functions := make(map[string]placeholder.Function, len(placeholder.Functions))
+
+	for name := range placeholder.Functions {
+		functions[name] = func(args ...any) (any, error) {
+			call := &Call{Function: name}
+			for _, arg := range args {
+				call.Args = append(call.Args, parts(placeholder.String(arg), "", c))
+			}
+
+			if err := checkCall(call); err != nil {
+				return nil, err
+			}
+
+			c.calls = append(c.calls, call)
+
+			return callMarker(len(c.calls) - 1), nil
+		}
+	}
+
+	return functions
+}
+
+// call returns the call the marker stands in for, nil when there is none.
+func (c *Calls) call(index string) *Call {
+	i, err := strconv.Atoi(index)
+	if c == nil || err != nil || i >= len(c.calls) {
+		return nil
+	}
+
+	return c.calls[i]
+}
+
+// callMarker returns the marker standing in for the call, unlike variable names it has
+// an underscore.
+func callMarker(index int) string {
+	return "__YURL_CALL_" + strconv.Itoa(index) + "__"
+}
+
+// checkCall checks the arguments that the generated code needs to know beforehand.
+func checkCall(call *Call) error {
+	switch call.Function {
+	case "date":
+		if len(call.Args[0]) > 1 || (len(call.Args[0]) == 1 && call.Args[0][0].Literal == "") {
+			return fmt.Errorf("the layout must be a string known while generating the code")
+		}
+	case "randomInt":
+		low, lowOK := intLiteral(call.Args[0])
+		high, highOK := intLiteral(call.Args[1])
+		if lowOK && highOK && high < low {
+			return fmt.Errorf("max %d is less than min %d", high, low)
+		}
+	}
+
+	return nil
+}
+
+// intLiteral returns the integer when the argument is an integer literal, like 10.
+func intLiteral(arg []Part) (int, bool) {
+	if len(arg) != 1 || arg[0].Literal == "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(arg[0].Literal)
+
+	return n, err == nil
+}
+
+// isNow reports whether the argument is the value of now, used as a time rather than
+// parsed back from its text.
+func isNow(arg []Part) bool {
+	return len(arg) == 1 && arg[0].Call != nil && arg[0].Call.Function == "now"
+}
+
+// layout returns the literal layout of a call to date.
+func layout(call *Call) string {
+	if len(call.Args[0]) == 0 {
+		return ""
+	}
+
+	return call.Args[0][0].Literal
+}
+
+// goHelpers are the functions of the generated Go code used by calls, along with their imports.
+var goHelpers = map[string]struct {
+	code    string
+	imports []string
+}{
+	"uuid": {`
+// uuid returns a random (version 4) uuid.
+func uuid() string {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	check(err)
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+`, []string{"crypto/rand"}},
+	"parseTime": {`
+// parseTime parses an RFC 3339 time or a number of seconds since January 1, 1970 UTC.
+func parseTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	check(err)
+
+	return time.Unix(seconds, 0)
+}
+`, []string{"time", "strconv"}},
+	"randomInt": {`
+// randomInt returns a random integer between min and max, both included.
+func randomInt(min, max int64) string {
+	n, err := rand.Int(rand.Reader, big.NewInt(max-min+1))
+	check(err)
+
+	return strconv.FormatInt(min+n.Int64(), 10)
+}
+`, []string{"crypto/rand", "math/big", "strconv"}},
+	"parseInt": {`
+func parseInt(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	check(err)
+
+	return n
+}
+`, []string{"strconv"}},
+	"hmacSHA256": {`
+// hmacSHA256 returns the hex encoded HMAC-SHA256 of the value, signed with the key.
+func hmacSHA256(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+`, []string{"crypto/hmac", "crypto/sha256", "encoding/hex"}},
+}
+
+// goCall returns the Go expression of the call, args are the expressions of its arguments.
+// The helpers used by the expression are added to helpers.
+func goCall(call *Call, args []string, imports map[string]bool, helpers map[string]bool) string {
+	use := func(helper string) {
+		helpers[helper] = true
+		for _, pkg := range goHelpers[helper].imports {
+			imports[pkg] = true
+		}
+	}
+
+	integer := func(i int) string {
+		if n, ok := intLiteral(call.Args[i]); ok {
+			return strconv.Itoa(n)
+		}
+
+		use("parseInt")
+		return "parseInt(" + args[i] + ")"
+	}
+
+	timeArg := func(i int) string {
+		if isNow(call.Args[i]) {
+			imports["time"] = true
+			return "time.Now()"
+		}
+
+		use("parseTime")
+		return "parseTime(" + args[i] + ")"
+	}
+
+	switch call.Function {
+	case "uuid":
+		use("uuid")
+		return "uuid()"
+	case "now":
+		imports["time"] = true
+		return "time.Now().Format(time.RFC3339)"
+	case "date":
+		return timeArg(1) + ".Format(" + strconv.Quote(layout(call)) + ")"
+	case "unix":
+		imports["strconv"] = true
+		if len(args) == 0 {
+			imports["time"] = true
+			return "strconv.FormatInt(time.Now().Unix(), 10)"
+		}
+
+		return "strconv.FormatInt(" + timeArg(0) + ".Unix(), 10)"
+	case "randomInt":
+		use("randomInt")
+		return "randomInt(" + integer(0) + ", " + integer(1) + ")"
+	case "base64":
+		imports["encoding/base64"] = true
+		return "base64.StdEncoding.EncodeToString([]byte(" + args[0] + "))"
+	case "sha256":
+		imports["crypto/sha256"] = true
+		return `fmt.Sprintf("%x", sha256.Sum256([]byte(` + args[0] + `)))`
+	case "hmacSha256":
+		use("hmacSHA256")
+		return "hmacSHA256(" + args[0] + ", " + args[1] + ")"
+	case "urlquery":
+		imports["net/url"] = true
+		return "url.QueryEscape(" + args[0] + ")"
+	case "concat":
+		return concatExpression(args)
+	}
+
+	return `""`
+}
+
+// pythonHelpers are the functions of the generated Python code used by calls, along with their imports.
+var pythonHelpers = map[string]struct {
+	code    string
+	imports []string
+}{
+	"parse_time": {`def parse_time(value):
+    """Parses an RFC 3339 time or a number of seconds since January 1, 1970 UTC."""
+    if isinstance(value, datetime.datetime):
+        return value
+    if value.lstrip("-").isdigit():
+        return datetime.datetime.fromtimestamp(int(value)).astimezone()
+    return datetime.datetime.fromisoformat(value.replace("Z", "+00:00"))
+
+
+`, []string{"datetime"}},
+	"date": {`GO_LAYOUT = re.compile(
+    r"January|Jan|Monday|Mon|MST|2006|[Z-]07:00:00|[Z-]070000|[Z-]07:00|[Z-]0700|[Z-]07"
+    r"|002|__2|_2|01|02|03|04|05|06|15|PM|pm|[.,](?:0+|9+)(?!\d)|1|2|3|4|5"
+)
+
+
+def date(layout, value):
+    """Formats the time using the Go layout, for example 2006-01-02."""
+    t = parse_time(value)
+
+    def element(match):
+        e = match.group(0)
+        offset = int(t.utcoffset().total_seconds()) if t.utcoffset() else 0
+        if e[0] in "Z-" and e[1:3] == "07":
+            if e[0] == "Z" and offset == 0:
+                return "Z"
+            fields = [abs(offset) // 3600, abs(offset) // 60 % 60, abs(offset) % 60]
+            count = (len(e.replace(":", "")) - 1) // 2
+            separator = ":" if ":" in e else ""
+            return ("-" if offset < 0 else "+") + separator.join(f"{n:02d}" for n in fields[:count])
+        if e[0] in ".,":
+            digits = f"{t.microsecond:06d}000"[: len(e) - 1]
+            if e[1] == "9":
+                digits = digits.rstrip("0")
+                return "." + digits if digits else ""
+            return e[0] + digits
+        hour12 = t.hour % 12 or 12
+        # Times with an offset but no time zone are named after the offset, like Go does
+        zone = t.tzname() or ""
+        if zone.startswith("UTC") and zone != "UTC":
+            zone = t.strftime("%z")
+        return {
+            "January": t.strftime("%B"), "Jan": t.strftime("%b"), "Monday": t.strftime("%A"),
+            "Mon": t.strftime("%a"), "MST": zone, "2006": f"{t.year:04d}",
+            "002": f"{t.timetuple().tm_yday:03d}", "__2": f"{t.timetuple().tm_yday:>3}",
+            "_2": f"{t.day:>2}", "01": f"{t.month:02d}", "02": f"{t.day:02d}", "03": f"{hour12:02d}",
+            "04": f"{t.minute:02d}", "05": f"{t.second:02d}", "06": f"{t.year % 100:02d}",
+            "15": f"{t.hour:02d}", "PM": "PM" if t.hour >= 12 else "AM", "pm": "pm" if t.hour >= 12 else "am",
+            "1": str(t.month), "2": str(t.day), "3": str(hour12), "4": str(t.minute), "5": str(t.second),
+        }[e]
+
+    return GO_LAYOUT.sub(element, layout)
+
+
+`, []string{"re"}},
+}
+
+// pythonCall returns the Python expression of the call, args are the expressions of its
+// arguments. The helpers used by the expression are added to helpers.
+func pythonCall(call *Call, args []string, imports map[string]bool, helpers map[string]bool) string {
+	use := func(names ...string) {
+		for _, helper := range names {
+			helpers[helper] = true
+			for _, module := range pythonHelpers[helper].imports {
+				imports[module] = true
+			}
+		}
+	}
+
+	integer := func(i int) string {
+		if n, ok := intLiteral(call.Args[i]); ok {
+			return strconv.Itoa(n)
+		}
+
+		return "int(" + args[i] + ")"
+	}
+
+	switch call.Function {
+	case "uuid":
+		imports["uuid"] = true
+		return "str(uuid.uuid4())"
+	case "now":
+		imports["datetime"] = true
+		return `datetime.datetime.now().astimezone().isoformat(timespec="seconds")`
+	case "date":
+		use("parse_time", "date")
+		if isNow(call.Args[1]) {
+			return "date(" + quoteJSON(layout(call)) + ", datetime.datetime.now().astimezone())"
+		}
+
+		return "date(" + quoteJSON(layout(call)) + ", " + args[1] + ")"
+	case "unix":
+		if len(args) == 0 || isNow(call.Args[0]) {
+			imports["datetime"] = true
+			return "str(int(datetime.datetime.now().timestamp()))"
+		}
+
+		use("parse_time")
+		return "str(int(parse_time(" + args[0] + ").timestamp()))"
+	case "randomInt":
+		imports["random"] = true
+		return "str(random.randint(" + integer(0) + ", " + integer(1) + "))"
+	case "base64":
+		imports["base64"] = true
+		return "base64.b64encode(" + args[0] + ".encode()).decode()"
+	case "sha256":
+		imports["hashlib"] = true
+		return "hashlib.sha256(" + args[0] + ".encode()).hexdigest()"
+	case "hmacSha256":
+		imports["hashlib"] = true
+		imports["hmac"] = true
+		return "hmac.new(" + args[0] + ".encode(), " + args[1] + ".encode(), hashlib.sha256).hexdigest()"
+	case "urlquery":
+		return "urllib.parse.quote_plus(" + args[0] + ")"
+	case "concat":
+		return concatExpression(args)
+	}
+
+	return `""`
+}
+
+// jsHelpers are the functions of the generated JavaScript code used by calls.
+var jsHelpers = map[string]string{
+	"parseTime": `// parseTime parses an RFC 3339 time or a number of seconds since January 1, 1970 UTC.
+function parseTime(value) {
+  if (value instanceof Date) {
+    return value;
+  }
+  return /^-?\d+$/.test(value) ? new Date(Number(value) * 1000) : new Date(value);
+}
+
+`,
+	"formatDate": `// formatDate formats the time using the Go layout, for example 2006-01-02. Times with an
+// offset, like 2024-05-01T10:00:00+02:00, are formatted in it, other times in the local one.
+function formatDate(layout, value) {
+  const match = typeof value === "string" && /(Z|([+-])(\d\d):(\d\d))$/.exec(value);
+  const date = parseTime(value);
+  const offset = !match ? -date.getTimezoneOffset()
+    : match[1] === "Z" ? 0 : (match[2] === "-" ? -1 : 1) * (Number(match[3]) * 60 + Number(match[4]));
+  // The UTC getters of the shifted time return the fields of the time in its offset
+  const t = new Date(date.getTime() + offset * 60000);
+  const pad = (n, width = 2, fill = "0") => String(n).padStart(width, fill);
+  const hour12 = t.getUTCHours() % 12 || 12;
+  const day = Math.floor((t - Date.UTC(t.getUTCFullYear(), 0, 1)) / 86400000) + 1;
+  const name = (options) => t.toLocaleString("en-US", { ...options, timeZone: "UTC" });
+  const sign = offset < 0 ? "-" : "+";
+  const zone = () => match ? (offset === 0 ? "UTC" : sign + pad(Math.floor(Math.abs(offset) / 60)) + pad(Math.abs(offset) % 60))
+    : new Intl.DateTimeFormat("en-US", { timeZoneName: "short" }).formatToParts(date).find((part) => part.type === "timeZoneName").value;
+  const elements = {
+    January: () => name({ month: "long" }), Jan: () => name({ month: "short" }),
+    Monday: () => name({ weekday: "long" }), Mon: () => name({ weekday: "short" }), MST: zone,
+    2006: () => pad(t.getUTCFullYear(), 4), "002": () => pad(day, 3), __2: () => pad(day, 3, " "),
+    _2: () => pad(t.getUTCDate(), 2, " "), "01": () => pad(t.getUTCMonth() + 1), "02": () => pad(t.getUTCDate()),
+    "03": () => pad(hour12), "04": () => pad(t.getUTCMinutes()), "05": () => pad(t.getUTCSeconds()),
+    "06": () => pad(t.getUTCFullYear() % 100), 15: () => pad(t.getUTCHours()),
+    PM: () => (t.getUTCHours() >= 12 ? "PM" : "AM"), pm: () => (t.getUTCHours() >= 12 ? "pm" : "am"),
+    1: () => String(t.getUTCMonth() + 1), 2: () => String(t.getUTCDate()), 3: () => String(hour12),
+    4: () => String(t.getUTCMinutes()), 5: () => String(t.getUTCSeconds()),
+  };
+  const pattern = /January|Jan|Monday|Mon|MST|2006|[Z-]07(?::?00){0,2}|002|__2|_2|0[1-6]|15|PM|pm|[.,](?:0+|9+)(?!\d)|[1-5]/g;
+  return layout.replace(pattern, (e) => {
+    if (/^[Z-]07/.test(e)) {
+      if (e[0] === "Z" && offset === 0) {
+        return "Z";
+      }
+      const fields = [pad(Math.floor(Math.abs(offset) / 60)), pad(Math.abs(offset) % 60), "00"];
+      const count = (e.replace(/:/g, "").length - 1) / 2;
+      return sign + fields.slice(0, count).join(e.includes(":") ? ":" : "");
+    }
+    if (/^[.,]/.test(e)) {
+      const digits = pad(t.getUTCMilliseconds(), 3).padEnd(e.length - 1, "0").slice(0, e.length - 1);
+      if (e[1] === "9") {
+        const trimmed = digits.replace(/0+$/, "");
+        return trimmed ? "." + trimmed : "";
+      }
+      return e[0] + digits;
+    }
+    return elements[e]();
+  });
+}
+
+`,
+}
+
+// jsCall returns the JavaScript expression of the call, args are the expressions of its
+// arguments. The helpers used by the expression are added to helpers.
+func jsCall(call *Call, args []string, helpers map[string]bool) string {
+	integer := func(i int, add int) string {
+		if n, ok := intLiteral(call.Args[i]); ok {
+			return strconv.Itoa(n + add)
+		}
+
+		if add > 0 {
+			return "Number(" + args[i] + ") + " + strconv.Itoa(add)
+		}
+
+		return "Number(" + args[i] + ")"
+	}
+
+	switch call.Function {
+	case "uuid":
+		return `require("node:crypto").randomUUID()`
+	case "now":
+		return "new Date().toISOString()"
+	case "date":
+		helpers["parseTime"] = true
+		helpers["formatDate"] = true
+		if isNow(call.Args[1]) {
+			return "formatDate(" + quoteJSON(layout(call)) + ", new Date())"
+		}
+
+		return "formatDate(" + quoteJSON(layout(call)) + ", " + args[1] + ")"
+	case "unix":
+		if len(args) == 0 || isNow(call.Args[0]) {
+			return "String(Math.floor(Date.now() / 1000))"
+		}
+
+		helpers["parseTime"] = true
+		return "String(Math.floor(parseTime(" + args[0] + ").getTime() / 1000))"
+	case "randomInt":
+		// The max of crypto.randomInt is excluded
+		return `String(require("node:crypto").randomInt(` + integer(0, 0) + ", " + integer(1, 1) + "))"
+	case "base64":
+		return "Buffer.from(" + args[0] + `).toString("base64")`
+	case "sha256":
+		return `require("node:crypto").createHash("sha256").update(` + args[0] + `).digest("hex")`
+	case "hmacSha256":
+		return `require("node:crypto").createHmac("sha256", ` + args[0] + ").update(" + args[1] + `).digest("hex")`
+	case "urlquery":
+		return "encodeURIComponent(" + args[0] + `).replace(/%20/g, "+")`
+	case "concat":
+		return concatExpression(args)
+	}
+
+	return `""`
+}
+
+// concatExpression returns the expression joining the strings, the same in every language.
+func concatExpression(args []string) string {
+	if len(args) == 0 {
+		return `""`
+	}
+
+	return "(" + strings.Join(args, " + ") + ")"
+}
+
+// helperCode returns the code of the helpers, sorted by name.
+func helperCode(helpers map[string]bool, code func(name string) string) string {
+	names := make([]string, 0, len(helpers))
+	for name := range helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(code(name))
+	}
+
+	return b.String()
+}
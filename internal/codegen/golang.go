@@ -0,0 +1,274 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goReserved are the keywords, predeclared identifiers and the names used by the generated Go code.
+var goReserved = setOf(
+	"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+	"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct",
+	"switch", "type", "var", "any", "bool", "byte", "error", "float64", "int", "len", "nil", "string",
+	"true", "false", "main", "err", "send", "decode", "lookup", "text", "check", "jsonString",
+	"uuid", "parseTime", "parseInt", "randomInt", "hmacSHA256",
+	"base64", "big", "bytes", "fmt", "hex", "hmac", "http", "io", "json", "os", "rand", "sha256", "strconv",
+	"strings", "time", "url",
+)
+
+func generateGo(program *Program) (string, error) {
+	var b strings.Builder
+	imports := setOf("fmt", "io", "net/http")
+
+	names := make(map[string]string)
+	name := func(variable string) string {
+		if _, ok := names[variable]; !ok {
+			names[variable] = identifier(variable, goReserved)
+		}
+		return names[variable]
+	}
+
+	helpers := make(map[string]bool)
+	usesJSONString := false
+
+	var expression func(parts []Part) string
+	expression = func(parts []Part) string {
+		if len(parts) == 0 {
+			return `""`
+		}
+
+		expressions := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part.Variable == "" && part.Call == nil {
+				expressions = append(expressions, strconv.Quote(part.Literal))
+				continue
+			}
+
+			var value string
+			if part.Call != nil {
+				args := make([]string, 0, len(part.Call.Args))
+				for _, arg := range part.Call.Args {
+					args = append(args, expression(arg))
+				}
+				value = goCall(part.Call, args, imports, helpers)
+			} else {
+				value = name(part.Variable)
+			}
+
+			switch part.Escape {
+			case "path":
+				imports["net/url"] = true
+				expressions = append(expressions, "url.PathEscape("+value+")")
+			case "query":
+				imports["net/url"] = true
+				expressions = append(expressions, "url.QueryEscape("+value+")")
+			case "json":
+				imports["encoding/json"] = true
+				usesJSONString = true
+				expressions = append(expressions, "jsonString("+value+")")
+			default:
+				expressions = append(expressions, value)
+			}
+		}
+
+		return strings.Join(expressions, " + ")
+	}
+
+	declared := make(map[string]bool)
+	usesLookup := false
+
+	fmt.Fprintf(&b, "func main() {\n")
+
+	if len(program.Inputs) > 0 {
+		imports["os"] = true
+
+		fmt.Fprintf(&b, "// Variables without a value are read from environment variables.\n")
+		for _, input := range program.Inputs {
+			fmt.Fprintf(&b, "%s := os.Getenv(%q)\n", name(input.Name), input.EnvVar)
+			declared[input.Name] = true
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	for i, request := range program.Requests {
+		last := i == len(program.Requests)-1
+		prefix := requestIdentifier(request.Name)
+
+		fmt.Fprintf(&b, "// %s\n", request.Name)
+
+		body := "nil"
+		switch {
+		case request.BodyFile != "":
+			imports["os"] = true
+			imports["bytes"] = true
+			body = "bytes.NewReader(" + prefix + "File)"
+
+			fmt.Fprintf(&b, "%sFile, err := os.ReadFile(%q)\n", prefix, request.BodyFile)
+			fmt.Fprintf(&b, "check(err)\n\n")
+		case request.HasBody:
+			imports["strings"] = true
+			body = "strings.NewReader(" + expression(request.Body) + ")"
+		}
+		fmt.Fprintf(&b, "%sRequest, err := http.NewRequest(%q, %s, %s)\n", prefix, request.Method, expression(request.URL), body)
+		fmt.Fprintf(&b, "check(err)\n\n")
+
+		for _, header := range request.Headers {
+			fmt.Fprintf(&b, "%sRequest.Header.Add(%q, %s)\n", prefix, header.Name, expression(header.Value))
+		}
+		if len(request.Headers) > 0 {
+			fmt.Fprintf(&b, "\n")
+		}
+
+		if len(request.Exports) == 0 && !last {
+			fmt.Fprintf(&b, "send(%sRequest)\n\n", prefix)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%sBody := send(%sRequest)\n", prefix, prefix)
+
+		if len(request.Exports) > 0 {
+			imports["encoding/json"] = true
+			usesLookup = true
+
+			fmt.Fprintf(&b, "%sResponse := decode(%sBody)\n", prefix, prefix)
+
+			for _, export := range request.Exports {
+				assign := ":="
+				if declared[export.Name] {
+					assign = "="
+				}
+				declared[export.Name] = true
+
+				fmt.Fprintf(&b, "%s %s text(lookup(%sResponse%s))\n", name(export.Name), assign, prefix, goPath(export.Path))
+			}
+		}
+
+		if last {
+			fmt.Fprintf(&b, "\nfmt.Println(string(%sBody))\n", prefix)
+			for _, export := range request.Exports {
+				fmt.Fprintf(&b, "fmt.Println(%q, %s)\n", export.Name+":", name(export.Name))
+			}
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n")
+	}
+
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, `// send sends the request and returns the body of the response.
+func send(request *http.Request) []byte {
+	response, err := http.DefaultClient.Do(request)
+	check(err)
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	check(err)
+
+	return body
+}
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+`)
+
+	if usesLookup {
+		fmt.Fprintf(&b, `
+func decode(body []byte) any {
+	var value any
+	check(json.Unmarshal(body, &value))
+
+	return value
+}
+
+// lookup returns the value at the path, made of object keys and array indexes.
+func lookup(value any, path ...any) any {
+	for _, key := range path {
+		switch key := key.(type) {
+		case string:
+			object, _ := value.(map[string]any)
+			value = object[key]
+		case int:
+			array, _ := value.([]any)
+			if key >= len(array) {
+				return nil
+			}
+			value = array[key]
+		}
+	}
+
+	return value
+}
+
+func text(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	return fmt.Sprintf("%%v", value)
+}
+`)
+	}
+
+	b.WriteString(helperCode(helpers, func(name string) string {
+		return goHelpers[name].code
+	}))
+
+	if usesJSONString {
+		fmt.Fprintf(&b, `
+// jsonString escapes the value to be written inside a json string.
+func jsonString(value string) string {
+	encoded, err := json.Marshal(value)
+	check(err)
+
+	return string(encoded[1 : len(encoded)-1])
+}
+`)
+	}
+
+	packages := make([]string, 0, len(imports))
+	for pkg := range imports {
+		packages = append(packages, strconv.Quote(pkg))
+	}
+	sort.Strings(packages)
+
+	source := fmt.Sprintf("// Code generated by yurl from the request %s.\n\npackage main\n\nimport (\n%s\n)\n\n%s",
+		program.Name, strings.Join(packages, "\n"), b.String())
+
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return "", fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return string(formatted), nil
+}
+
+func goPath(path []any) string {
+	var b strings.Builder
+
+	for _, segment := range path {
+		switch segment := segment.(type) {
+		case string:
+			b.WriteString(", " + strconv.Quote(segment))
+		case int:
+			b.WriteString(", " + strconv.Itoa(segment))
+		}
+	}
+
+	return b.String()
+}
+
+func setOf(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
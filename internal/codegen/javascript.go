@@ -0,0 +1,193 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsReserved are the keywords, globals and the names used by the generated JavaScript code.
+var jsReserved = setOf(
+	"await", "break", "case", "catch", "class", "const", "continue", "debugger", "default", "delete", "do",
+	"else", "enum", "export", "extends", "false", "finally", "for", "function", "if", "import", "in",
+	"instanceof", "let", "new", "null", "return", "super", "switch", "this", "throw", "true", "try",
+	"typeof", "undefined", "var", "void", "while", "with", "yield",
+	"JSON", "console", "process", "fetch", "require", "main", "send", "lookup", "text",
+	"Buffer", "Date", "Intl", "Math", "Number", "String", "encodeURIComponent", "parseTime", "formatDate",
+)
+
+func generateJS(program *Program) string {
+	var b strings.Builder
+
+	name := func(variable string) string {
+		return identifier(variable, jsReserved)
+	}
+
+	helpers := make(map[string]bool)
+
+	var expression func(parts []Part) string
+	expression = func(parts []Part) string {
+		if len(parts) == 0 {
+			return `""`
+		}
+
+		expressions := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part.Variable == "" && part.Call == nil {
+				expressions = append(expressions, quoteJSON(part.Literal))
+				continue
+			}
+
+			var value string
+			if part.Call != nil {
+				args := make([]string, 0, len(part.Call.Args))
+				for _, arg := range part.Call.Args {
+					args = append(args, expression(arg))
+				}
+				value = jsCall(part.Call, args, helpers)
+			} else {
+				value = name(part.Variable)
+			}
+
+			switch part.Escape {
+			case "json":
+				expressions = append(expressions, "JSON.stringify("+value+").slice(1, -1)")
+			case "path", "query":
+				expressions = append(expressions, "encodeURIComponent("+value+")")
+			default:
+				expressions = append(expressions, value)
+			}
+		}
+
+		return strings.Join(expressions, " + ")
+	}
+
+	fmt.Fprintf(&b, "async function main() {\n")
+
+	if len(program.Inputs) > 0 {
+		fmt.Fprintf(&b, "  // Variables without a value are read from environment variables.\n")
+		for _, input := range program.Inputs {
+			fmt.Fprintf(&b, "  let %s = process.env.%s ?? \"\";\n", name(input.Name), input.EnvVar)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	declared := make(map[string]bool)
+	for _, input := range program.Inputs {
+		declared[input.Name] = true
+	}
+
+	for i, request := range program.Requests {
+		last := i == len(program.Requests)-1
+		prefix := requestIdentifier(request.Name)
+
+		headers := make([]string, 0, len(request.Headers))
+		for _, header := range request.Headers {
+			headers = append(headers, "      "+quoteJSON(header.Name)+": "+expression(header.Value)+",\n")
+		}
+
+		body := ""
+		switch {
+		case request.BodyFile != "":
+			body = ",\n    require(\"node:fs\").readFileSync(" + quoteJSON(request.BodyFile) + ")"
+		case request.HasBody:
+			body = ",\n    " + expression(request.Body)
+		}
+
+		fmt.Fprintf(&b, "  // %s\n", request.Name)
+
+		call := fmt.Sprintf("await send(\n    %s,\n    %s,\n    {\n%s    }%s\n  );\n", quoteJSON(request.Method), expression(request.URL), strings.Join(headers, ""), body)
+
+		if len(request.Exports) == 0 && !last {
+			fmt.Fprintf(&b, "  %s\n", call)
+			continue
+		}
+
+		fmt.Fprintf(&b, "  const %sBody = %s", prefix, call)
+
+		if len(request.Exports) > 0 {
+			fmt.Fprintf(&b, "  const %sResponse = JSON.parse(%sBody);\n", prefix, prefix)
+			for _, export := range request.Exports {
+				declaration := "let "
+				if declared[export.Name] {
+					declaration = ""
+				}
+				declared[export.Name] = true
+
+				fmt.Fprintf(&b, "  %s%s = text(lookup(%sResponse%s));\n", declaration, name(export.Name), prefix, jsonPath(export.Path))
+			}
+		}
+
+		if last {
+			fmt.Fprintf(&b, "\n  console.log(%sBody);\n", prefix)
+			for _, export := range request.Exports {
+				fmt.Fprintf(&b, "  console.log(%s, %s);\n", quoteJSON(export.Name+":"), name(export.Name))
+			}
+		}
+
+		fmt.Fprintf(&b, "\n")
+	}
+
+	// Helpers are known once the code of the requests is generated
+	var head strings.Builder
+
+	fmt.Fprintf(&head, "// Code generated by yurl from the request %s.\n", program.Name)
+	fmt.Fprintf(&head, "// Requires Node.js 18 or later.\n\n")
+
+	fmt.Fprintf(&head, `// send sends the request and returns the body of the response.
+async function send(method, url, headers, body) {
+  const response = await fetch(url, { method, headers, body });
+  return await response.text();
+}
+
+// lookup returns the value at the path, made of object keys and array indexes.
+function lookup(value, ...path) {
+  for (const key of path) {
+    value = value == null ? undefined : value[key];
+  }
+  return value;
+}
+
+function text(value) {
+  if (typeof value === "string") {
+    return value;
+  }
+  return typeof value === "object" && value !== null ? JSON.stringify(value) : String(value);
+}
+
+`)
+	head.WriteString(helperCode(helpers, func(name string) string {
+		return jsHelpers[name]
+	}))
+
+	return head.String() + strings.TrimRight(b.String(), "\n") + "\n}\n\nmain();\n"
+}
+
+// quoteJSON quotes s as a JSON string, which is a valid string literal in JavaScript and Python.
+func quoteJSON(s string) string {
+	var b bytes.Buffer
+
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// jsonPath returns the arguments passed to lookup for the path.
+func jsonPath(path []any) string {
+	var b strings.Builder
+
+	for _, segment := range path {
+		switch segment := segment.(type) {
+		case string:
+			b.WriteString(", " + quoteJSON(segment))
+		case int:
+			b.WriteString(", " + strconv.Itoa(segment))
+		}
+	}
+
+	return b.String()
+}
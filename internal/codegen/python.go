@@ -0,0 +1,209 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// pythonReserved are the keywords, builtins and the names used by the generated Python code.
+var pythonReserved = setOf(
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue", "def",
+	"del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda",
+	"nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+	"id", "type", "list", "dict", "str", "int", "float", "bool", "print", "input", "object", "format",
+	"send", "lookup", "text", "date", "parse_time", "GO_LAYOUT",
+	"base64", "datetime", "hashlib", "hmac", "json", "os", "random", "re", "urllib", "uuid",
+)
+
+func generatePython(program *Program) string {
+	var b strings.Builder
+
+	name := func(variable string) string {
+		name := snakeCase(variable)
+		if unicode.IsDigit(rune(name[0])) {
+			name = "v" + name
+		}
+
+		for pythonReserved[name] {
+			name += "_"
+		}
+
+		return name
+	}
+
+	imports := setOf("json", "os", "urllib.error", "urllib.parse", "urllib.request")
+	helpers := make(map[string]bool)
+
+	var expression func(parts []Part) string
+	expression = func(parts []Part) string {
+		if len(parts) == 0 {
+			return `""`
+		}
+
+		expressions := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part.Variable == "" && part.Call == nil {
+				expressions = append(expressions, quoteJSON(part.Literal))
+				continue
+			}
+
+			var value string
+			if part.Call != nil {
+				args := make([]string, 0, len(part.Call.Args))
+				for _, arg := range part.Call.Args {
+					args = append(args, expression(arg))
+				}
+				value = pythonCall(part.Call, args, imports, helpers)
+			} else {
+				value = name(part.Variable)
+			}
+
+			switch part.Escape {
+			case "path":
+				expressions = append(expressions, "urllib.parse.quote("+value+`, safe="")`)
+			case "query":
+				expressions = append(expressions, "urllib.parse.quote_plus("+value+")")
+			case "json":
+				expressions = append(expressions, "json.dumps("+value+")[1:-1]")
+			default:
+				expressions = append(expressions, value)
+			}
+		}
+
+		return strings.Join(expressions, " + ")
+	}
+
+	if len(program.Inputs) > 0 {
+		fmt.Fprintf(&b, "# Variables without a value are read from environment variables.\n")
+		for _, input := range program.Inputs {
+			fmt.Fprintf(&b, "%s = os.environ.get(%s, \"\")\n", name(input.Name), quoteJSON(input.EnvVar))
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	for i, request := range program.Requests {
+		last := i == len(program.Requests)-1
+		prefix := snakeCase(requestIdentifier(request.Name))
+
+		headers := make([]string, 0, len(request.Headers))
+		for _, header := range request.Headers {
+			headers = append(headers, "    "+quoteJSON(header.Name)+": "+expression(header.Value)+",\n")
+		}
+
+		body := ""
+		switch {
+		case request.BodyFile != "":
+			body = ",\n    open(" + quoteJSON(request.BodyFile) + `, "rb").read()`
+		case request.HasBody:
+			body = ",\n    " + expression(request.Body)
+		}
+
+		fmt.Fprintf(&b, "# %s\n", request.Name)
+
+		call := fmt.Sprintf("send(\n    %s,\n    %s,\n    {\n%s    }%s,\n)\n", quoteJSON(request.Method), expression(request.URL), indent(strings.Join(headers, ""), "    "), body)
+
+		if len(request.Exports) == 0 && !last {
+			fmt.Fprintf(&b, "%s\n", call)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s_body = %s", prefix, call)
+
+		if len(request.Exports) > 0 {
+			fmt.Fprintf(&b, "%s_response = json.loads(%s_body)\n", prefix, prefix)
+			for _, export := range request.Exports {
+				fmt.Fprintf(&b, "%s = text(lookup(%s_response%s))\n", name(export.Name), prefix, jsonPath(export.Path))
+			}
+		}
+
+		if last {
+			fmt.Fprintf(&b, "\nprint(%s_body)\n", prefix)
+			for _, export := range request.Exports {
+				fmt.Fprintf(&b, "print(%s, %s)\n", quoteJSON(export.Name+":"), name(export.Name))
+			}
+		}
+
+		fmt.Fprintf(&b, "\n")
+	}
+
+	// Imports and helpers are known once the code of the requests is generated
+	var head strings.Builder
+
+	fmt.Fprintf(&head, "# Code generated by yurl from the request %s.\n\n", program.Name)
+
+	modules := make([]string, 0, len(imports))
+	for module := range imports {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	for _, module := range modules {
+		fmt.Fprintf(&head, "import %s\n", module)
+	}
+	fmt.Fprintf(&head, "\n\n")
+
+	fmt.Fprintf(&head, `def send(method, url, headers, body=None):
+    """Sends the request and returns the body of the response."""
+    data = body.encode() if isinstance(body, str) else body
+    request = urllib.request.Request(url, data=data, headers=headers, method=method)
+    try:
+        with urllib.request.urlopen(request) as response:
+            return response.read().decode()
+    except urllib.error.HTTPError as error:
+        return error.read().decode()
+
+
+def lookup(value, *path):
+    """Returns the value at the path, made of object keys and array indexes."""
+    for key in path:
+        if isinstance(key, int):
+            value = value[key] if isinstance(value, list) and key < len(value) else None
+        else:
+            value = value.get(key) if isinstance(value, dict) else None
+    return value
+
+
+def text(value):
+    if isinstance(value, str):
+        return value
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    if isinstance(value, (dict, list)):
+        return json.dumps(value)
+    return str(value)
+
+
+`)
+	head.WriteString(helperCode(helpers, func(name string) string {
+		return pythonHelpers[name].code
+	}))
+
+	return head.String() + strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// snakeCase converts the camel cased name to snake case: userId becomes user_id.
+func snakeCase(name string) string {
+	var result strings.Builder
+
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToLower(r))
+	}
+
+	return result.String()
+}
+
+func indent(s string, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,360 @@
+package curl
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "curl https://example.com", want: []string{"curl", "https://example.com"}},
+		{command: `curl -H 'A: b c' -d "x=\"y\""`, want: []string{"curl", "-H", "A: b c", "-d", `x="y"`}},
+		{command: "curl \\\n  -X PUT \\\r\n  url", want: []string{"curl", "-X", "PUT", "url"}},
+		{command: `curl -d $'a\nb\'c'`, want: []string{"curl", "-d", "a\nb'c"}},
+		{command: `curl a\ b`, want: []string{"curl", "a b"}},
+		{command: `curl -d ''`, want: []string{"curl", "-d", ""}},
+	}
+
+	for _, tt := range tests {
+		got, err := Split(tt.command)
+		if err != nil {
+			t.Errorf("Split(%q) error = %v", tt.command, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	if _, err := Split("curl 'unterminated"); err != ErrUnterminatedQuote {
+		t.Errorf("Split() error = %v, want %v", err, ErrUnterminatedQuote)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    models.HttpRequestTemplate
+		wantErr string
+	}{
+		{
+			name:    "get",
+			command: "curl https://api.example.com/users?page=2",
+			want: models.HttpRequestTemplate{
+				Method: "GET",
+				URL:    "https://api.example.com/users",
+				Query:  map[string]string{"page": "2"},
+			},
+		},
+		{
+			name:    "url without scheme",
+			command: "curl -s localhost:8080/health",
+			want:    models.HttpRequestTemplate{Method: "GET", URL: "http://localhost:8080/health"},
+		},
+		{
+			name:    "json",
+			command: `curl -X PUT https://api.example.com/todos/3 -H 'Content-Type: application/json' -H 'Authorization: Bearer abc' --data-raw '{"title": "Buy milk"}'`,
+			want: models.HttpRequestTemplate{
+				Method:   "PUT",
+				URL:      "https://api.example.com/todos/3",
+				Headers:  map[string]string{"Authorization": "Bearer abc"},
+				JsonBody: models.JSONBody{Text: `{"title": "Buy milk"}`},
+			},
+		},
+		{
+			name:    "form",
+			command: "curl https://example.com/login -d user=a -d 'pass=b c'",
+			want: models.HttpRequestTemplate{
+				Method:   "POST",
+				URL:      "https://example.com/login",
+				FormBody: models.FormBody{"user": {"a"}, "pass": {"b c"}},
+			},
+		},
+		{
+			name:    "data as query",
+			command: "curl -G https://example.com/search --data-urlencode 'q=a b'",
+			want: models.HttpRequestTemplate{
+				Method: "GET",
+				URL:    "https://example.com/search",
+				Query:  map[string]string{"q": "a b"},
+			},
+		},
+		{
+			name:    "grouped flags",
+			command: "curl -sSI https://example.com",
+			want:    models.HttpRequestTemplate{Method: "HEAD", URL: "https://example.com"},
+		},
+		{
+			name:    "basic auth and user agent",
+			command: "curl -u user:pass -A yurl https://example.com",
+			want: models.HttpRequestTemplate{
+				Method:  "GET",
+				URL:     "https://example.com",
+				Headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "User-Agent": "yurl"},
+			},
+		},
+		{
+			name:    "multipart",
+			command: "curl https://example.com/upload -H 'Content-Type: multipart/form-data' -F name=me -F 'avatar=@me.png;type=image/png;filename=avatar.png'",
+			want: models.HttpRequestTemplate{
+				Method: "POST",
+				URL:    "https://example.com/upload",
+				Multipart: []models.MultipartField{
+					{Name: "name", Value: "me"},
+					{Name: "avatar", File: "me.png", ContentType: "image/png", Filename: "avatar.png"},
+				},
+			},
+		},
+		{
+			name:    "data from a file",
+			command: "curl https://example.com/import --data-binary @payload.json",
+			want: models.HttpRequestTemplate{
+				Method:   "POST",
+				URL:      "https://example.com/import",
+				BodyFile: &models.BodyFile{Path: "payload.json"},
+			},
+		},
+		{
+			name:    "download over a socket",
+			command: "curl --unix-socket /var/run/docker.sock http://docker/images/json -o images.json -C - -N",
+			want: models.HttpRequestTemplate{
+				Method: "GET",
+				URL:    "http://docker/images/json",
+				Socket: "/var/run/docker.sock",
+				SaveTo: &models.SaveTo{Path: "images.json", Resume: true},
+				Stream: &models.Stream{},
+			},
+		},
+		{name: "missing url", command: "curl -s", wantErr: ErrMissingURL.Error()},
+		{name: "unsupported option", command: "curl --oauth2-bearer x https://example.com", wantErr: "curl option --oauth2-bearer is not supported"},
+		{name: "data and form", command: "curl https://example.com -d a=b -F c=d", wantErr: "can't be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.command)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		headers  map[string]string
+		body     string
+		template models.HttpRequestTemplate
+		want     string
+	}{
+		{
+			name:   "get",
+			method: "GET",
+			url:    "https://example.com/users?page=2",
+			want:   "curl 'https://example.com/users?page=2'",
+		},
+		{
+			name:   "head",
+			method: "HEAD",
+			url:    "https://example.com",
+			want:   "curl -I https://example.com",
+		},
+		{
+			name:    "post",
+			method:  "POST",
+			url:     "https://example.com/login",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:    "user=a&pass=it's",
+			want: "curl https://example.com/login \\\n" +
+				"  -H 'Content-Type: application/x-www-form-urlencoded' \\\n" +
+				`  --data-raw 'user=a&pass=it'\''s'`,
+		},
+		{
+			name:   "delete",
+			method: "DELETE",
+			url:    "https://example.com/todos/3",
+			want:   "curl -X DELETE https://example.com/todos/3",
+		},
+		{
+			name:     "socket and download",
+			method:   "GET",
+			url:      "http://docker/images/json",
+			template: models.HttpRequestTemplate{Socket: "/var/run/docker.sock", SaveTo: &models.SaveTo{Path: "images.json", Resume: true}},
+			want:     "curl http://docker/images/json \\\n  --unix-socket /var/run/docker.sock \\\n  -o images.json \\\n  -C -",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(newRequest(t, tt.method, tt.url, tt.headers, tt.body, tt.template))
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatParse checks that parsing a formatted request gives back the same request.
+func TestFormatParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		headers  map[string]string
+		body     string
+		template models.HttpRequestTemplate
+		want     models.HttpRequestTemplate
+	}{
+		{
+			name:   "get with query",
+			method: "GET",
+			url:    "https://example.com/search?q=a+b&page=2",
+			want: models.HttpRequestTemplate{
+				Method: "GET",
+				URL:    "https://example.com/search",
+				Query:  map[string]string{"q": "a b", "page": "2"},
+			},
+		},
+		{
+			name:   "head",
+			method: "HEAD",
+			url:    "https://example.com/files/1",
+			want:   models.HttpRequestTemplate{Method: "HEAD", URL: "https://example.com/files/1"},
+		},
+		{
+			name:    "json with quotes",
+			method:  "PATCH",
+			url:     "https://example.com/todos/3",
+			headers: map[string]string{"Content-Type": "application/json", "X-Note": "it's"},
+			body:    `{"title": "it's done"}`,
+			want: models.HttpRequestTemplate{
+				Method:   "PATCH",
+				URL:      "https://example.com/todos/3",
+				Headers:  map[string]string{"X-Note": "it's"},
+				JsonBody: models.JSONBody{Text: `{"title": "it's done"}`},
+			},
+		},
+		{
+			name:    "xml",
+			method:  "POST",
+			url:     "https://example.com/orders",
+			headers: map[string]string{"Content-Type": "application/xml"},
+			body:    "<order id=\"1\"/>",
+			want: models.HttpRequestTemplate{
+				Method:  "POST",
+				URL:     "https://example.com/orders",
+				XmlBody: "<order id=\"1\"/>",
+			},
+		},
+		{
+			name:   "multipart",
+			method: "POST",
+			url:    "https://example.com/upload",
+			template: models.HttpRequestTemplate{Multipart: []models.MultipartField{
+				{Name: "note", Value: "@not a file"},
+				{Name: "avatar", File: "me.png", Filename: "me.png", ContentType: "image/png"},
+			}},
+			want: models.HttpRequestTemplate{
+				Method: "POST",
+				URL:    "https://example.com/upload",
+				Multipart: []models.MultipartField{
+					{Name: "note", Value: "@not a file"},
+					{Name: "avatar", File: "me.png", Filename: "me.png", ContentType: "image/png"},
+				},
+			},
+		},
+		{
+			name:     "put from a file",
+			method:   "PUT",
+			url:      "https://example.com/import",
+			template: models.HttpRequestTemplate{BodyFile: &models.BodyFile{Path: "data dir/payload.json"}},
+			want: models.HttpRequestTemplate{
+				Method:   "PUT",
+				URL:      "https://example.com/import",
+				BodyFile: &models.BodyFile{Path: "data dir/payload.json"},
+			},
+		},
+		{
+			name:     "stream over a socket",
+			method:   "GET",
+			url:      "http://localhost/events",
+			template: models.HttpRequestTemplate{Socket: "/tmp/app.sock", Stream: &models.Stream{}},
+			want: models.HttpRequestTemplate{
+				Method: "GET",
+				URL:    "http://localhost/events",
+				Socket: "/tmp/app.sock",
+				Stream: &models.Stream{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := Format(newRequest(t, tt.method, tt.url, tt.headers, tt.body, tt.template))
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			got, err := Parse(command)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", command, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWebSocket(t *testing.T) {
+	template := models.HttpRequestTemplate{WebSocket: &models.WebSocket{}}
+
+	if _, err := Format(newRequest(t, "GET", "http://example.com/ws", nil, "", template)); err == nil {
+		t.Error("Format() of a websocket request succeeded")
+	}
+}
+
+func newRequest(t *testing.T, method, url string, headers map[string]string, body string, template models.HttpRequestTemplate) *models.HttpRequest {
+	t.Helper()
+
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body == "" {
+		request.Body = http.NoBody
+	}
+
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	return &models.HttpRequest{Template: &template, RawRequest: request}
+}
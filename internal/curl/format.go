@@ -0,0 +1,145 @@
+package curl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// Format formats the request as a curl command line, ready to be pasted in a POSIX shell.
+// Each option, after the url, is placed on its own line.
+func Format(httpRequest *models.HttpRequest) (string, error) {
+	if httpRequest.Template.WebSocket != nil {
+		return "", errors.New("websocket requests are not supported")
+	}
+
+	request := httpRequest.RawRequest
+	multipart := httpRequest.Template.Multipart
+
+	// Files that are not templates are sent by curl as they are, instead of being inlined
+	bodyFile := ""
+	if f := httpRequest.Template.BodyFile; f != nil && !f.Template {
+		bodyFile = f.Path
+	}
+
+	body := ""
+	if len(multipart) == 0 && bodyFile == "" {
+		var err error
+		if body, err = readBody(request); err != nil {
+			return "", err
+		}
+	}
+
+	hasBody := body != "" || len(multipart) > 0 || bodyFile != ""
+
+	command := "curl"
+
+	// curl sends GET, or POST when there is a body, on its own. HEAD is sent using -I,
+	// with -X HEAD curl waits for a body that never comes.
+	switch {
+	case request.Method == http.MethodHead && !hasBody:
+		command += " -I"
+	case !(request.Method == http.MethodGet && !hasBody) && !(request.Method == http.MethodPost && hasBody):
+		command += " -X " + request.Method
+	}
+
+	args := []string{command + " " + Quote(request.URL.String())}
+
+	if socket := httpRequest.Template.Socket; socket != "" {
+		args = append(args, "--unix-socket "+Quote(socket))
+	}
+
+	names := make([]string, 0, len(request.Header))
+	for name := range request.Header {
+		// curl sets the content type of multipart forms, along with the boundary, on its own
+		if len(multipart) > 0 && name == "Content-Type" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range request.Header[name] {
+			args = append(args, "-H "+Quote(name+": "+value))
+		}
+	}
+
+	for _, field := range multipart {
+		if field.File == "" {
+			// Unlike -F, values starting with @ or < are sent as they are
+			args = append(args, "--form-string "+Quote(field.Name+"="+field.Value))
+			continue
+		}
+
+		args = append(args, "-F "+Quote(field.Name+"=@"+field.File+";filename="+field.Filename+";type="+field.ContentType))
+	}
+
+	if body != "" {
+		args = append(args, "--data-raw "+Quote(body))
+	}
+
+	if bodyFile != "" {
+		args = append(args, "--data-binary "+Quote("@"+bodyFile))
+	}
+
+	if saveTo := httpRequest.Template.SaveTo; saveTo != nil {
+		args = append(args, "-o "+Quote(saveTo.Path))
+
+		if saveTo.Resume {
+			args = append(args, "-C -")
+		}
+	}
+
+	// Streamed responses are printed as they arrive
+	if stream := httpRequest.Template.Stream; stream != nil && !stream.Disabled {
+		args = append(args, "--no-buffer")
+	}
+
+	return strings.Join(args, " \\\n  "), nil
+}
+
+// Quote quotes s for a POSIX shell, using single quotes unless s is made
+// of characters that are safe without quotes.
+func Quote(s string) string {
+	if s != "" && strings.IndexFunc(s, needsQuote) == -1 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func needsQuote(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@%+,=", r))
+}
+
+// readBody reads the body of the request, leaving the request body readable.
+func readBody(request *http.Request) (string, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return "", nil
+	}
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+
+		content, err := io.ReadAll(body)
+		return string(content), err
+	}
+
+	content, err := io.ReadAll(request.Body)
+	if err != nil {
+		return "", err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(content))
+
+	return string(content), nil
+}
@@ -0,0 +1,506 @@
+// Package curl converts between curl command lines and requests.
+package curl
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("unterminated quote in curl command")
+	ErrMissingURL        = errors.New("no url found in curl command")
+)
+
+// flagsWithValue are the options, that take a value, which don't affect the request.
+var flagsWithValue = map[string]bool{
+	"-m": true, "--max-time": true,
+	"--connect-timeout": true,
+	"--retry":           true,
+	"-x":                true, "--proxy": true,
+	"-w": true, "--write-out": true,
+	"--cacert": true, "--cert": true, "--key": true,
+	"-c": true, "--cookie-jar": true,
+}
+
+// flagsWithoutValue are the options, without a value, which don't affect the request.
+var flagsWithoutValue = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true,
+	"-k": true, "--insecure": true,
+	"-L": true, "--location": true,
+	"-i": true, "--include": true,
+	"-v": true, "--verbose": true,
+	"-f": true, "--fail": true,
+	"-#": true, "--progress-bar": true,
+	"--compressed": true,
+	"--http1.1":    true,
+	"--http2":      true,
+	"--globoff":    true, "-g": true,
+}
+
+// Parse parses a curl command line into a request. The url of the request is always
+// absolute, query params of the url are moved to the query of the request.
+func Parse(command string) (models.HttpRequestTemplate, error) {
+	args, err := Split(command)
+	if err != nil {
+		return models.HttpRequestTemplate{}, err
+	}
+
+	return ParseArgs(args)
+}
+
+// ParseArgs parses an already split curl command line into a request.
+func ParseArgs(args []string) (models.HttpRequestTemplate, error) {
+	request := models.HttpRequestTemplate{
+		Headers: make(map[string]string),
+	}
+
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+
+	var (
+		rawURL    string
+		data      []string
+		dataFile  string
+		form      []models.MultipartField
+		dataAsGet bool
+		head      bool
+		noBuffer  bool
+		output    string
+		resume    bool
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if rawURL == "" {
+				rawURL = arg
+			}
+			continue
+		}
+
+		// Short options can be grouped, -sSG, or have their value attached, -XPOST
+		name, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			if isFlagGroup(arg) {
+				dataAsGet = dataAsGet || strings.Contains(arg, "G")
+				head = head || strings.Contains(arg, "I")
+				noBuffer = noBuffer || strings.Contains(arg, "N")
+				continue
+			}
+			name, value, hasValue = arg[:2], arg[2:], true
+		}
+
+		nextValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl option %s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch {
+		case flagsWithoutValue[name]:
+			continue
+		case name == "-G" || name == "--get":
+			dataAsGet = true
+			continue
+		case name == "-I" || name == "--head":
+			head = true
+			continue
+		case name == "-N" || name == "--no-buffer":
+			noBuffer = true
+			continue
+		case flagsWithValue[name]:
+			if _, err := nextValue(); err != nil {
+				return request, err
+			}
+			continue
+		}
+
+		value, err := nextValue()
+		if err != nil {
+			return request, err
+		}
+
+		switch name {
+		case "-X", "--request":
+			request.Method = strings.ToUpper(value)
+		case "-H", "--header":
+			key, headerValue, _ := strings.Cut(value, ":")
+			request.Headers[strings.TrimSpace(key)] = strings.TrimSpace(headerValue)
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(value, "@") {
+				if dataFile != "" {
+					return request, errors.New("only one data file is supported")
+				}
+				dataFile = value[1:]
+				continue
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, urlEncodeData(value))
+		case "--json":
+			data = append(data, value)
+			request.Headers["Content-Type"] = "application/json"
+			request.Headers["Accept"] = "application/json"
+		case "-u", "--user":
+			request.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(value))
+		case "-A", "--user-agent":
+			request.Headers["User-Agent"] = value
+		case "-e", "--referer":
+			request.Headers["Referer"] = value
+		case "-b", "--cookie":
+			request.Headers["Cookie"] = value
+		case "--url":
+			rawURL = value
+		case "-F", "--form":
+			field, err := parseFormField(value)
+			if err != nil {
+				return request, err
+			}
+			form = append(form, field)
+		case "-o", "--output":
+			output = value
+		case "-C", "--continue-at":
+			// Only resuming from the size of the file, -C -, is supported
+			if value != "-" {
+				return request, fmt.Errorf("curl option %s only supports -", name)
+			}
+			resume = true
+		case "--unix-socket":
+			request.Socket = value
+		case "--form-string":
+			fieldName, fieldValue, _ := strings.Cut(value, "=")
+			form = append(form, models.MultipartField{Name: fieldName, Value: fieldValue})
+		default:
+			return request, fmt.Errorf("curl option %s is not supported", name)
+		}
+	}
+
+	if rawURL == "" {
+		return request, ErrMissingURL
+	}
+
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	reqURL, err := url.Parse(rawURL)
+	if err != nil {
+		return request, err
+	}
+
+	query := reqURL.Query()
+	reqURL.RawQuery = ""
+
+	body := strings.Join(data, "&")
+
+	// With -G data is sent as query params
+	if dataAsGet && body != "" {
+		dataQuery, err := url.ParseQuery(body)
+		if err != nil {
+			return request, err
+		}
+		for key, values := range dataQuery {
+			query[key] = append(query[key], values...)
+		}
+		body = ""
+	}
+
+	request.URL = reqURL.String()
+
+	if len(query) > 0 {
+		request.Query = make(map[string]string, len(query))
+		for key := range query {
+			request.Query[key] = query.Get(key)
+		}
+	}
+
+	if len(form) > 0 && body != "" {
+		return request, errors.New("curl options -d and -F can't be used together")
+	}
+
+	if dataFile != "" && (body != "" || len(form) > 0 || dataAsGet) {
+		return request, errors.New("data from a file can't be combined with other data")
+	}
+
+	if request.Method == "" {
+		switch {
+		case head:
+			request.Method = "HEAD"
+		case body != "" || len(form) > 0 || dataFile != "":
+			request.Method = "POST"
+		default:
+			request.Method = "GET"
+		}
+	}
+
+	if body != "" {
+		setBody(&request, body)
+	}
+
+	if dataFile != "" {
+		request.BodyFile = &models.BodyFile{Path: dataFile}
+	}
+
+	if len(form) > 0 {
+		request.Multipart = form
+
+		// multipart sets the content type, along with the boundary, on its own
+		for key := range request.Headers {
+			if strings.EqualFold(key, "Content-Type") {
+				delete(request.Headers, key)
+			}
+		}
+	}
+
+	if output != "" {
+		request.SaveTo = &models.SaveTo{Path: output, Resume: resume}
+	}
+
+	// Responses curl isn't told to buffer are read as a stream
+	if noBuffer {
+		request.Stream = &models.Stream{}
+	}
+
+	if len(request.Headers) == 0 {
+		request.Headers = nil
+	}
+
+	return request, nil
+}
+
+// setBody sets the body on the request based on its content type. Just like curl
+// data without a content type is sent as application/x-www-form-urlencoded.
+func setBody(request *models.HttpRequestTemplate, body string) {
+	contentTypeKey := ""
+	for key := range request.Headers {
+		if strings.EqualFold(key, "Content-Type") {
+			contentTypeKey = key
+		}
+	}
+
+	contentType := strings.ToLower(request.Headers[contentTypeKey])
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		request.JsonBody = models.JSONBody{Text: body}
+
+		// jsonBody sets the content type on its own
+		if contentType == "application/json" {
+			delete(request.Headers, contentTypeKey)
+		}
+	case strings.Contains(contentType, "xml"):
+		request.XmlBody = body
+
+		// xmlBody sets the content type on its own
+		if contentType == "application/xml" {
+			delete(request.Headers, contentTypeKey)
+		}
+	case contentType == "" || contentType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(body)
+		if err != nil {
+			// Data that isn't a valid form is sent as it is
+			request.Body = body
+			request.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+			return
+		}
+
+		request.FormBody = models.NewFormBody(form)
+
+		// formBody sets the content type on its own
+		delete(request.Headers, contentTypeKey)
+	default:
+		request.Body = body
+	}
+}
+
+// parseFormField parses the value of -F, either name=value or name=@file followed
+// by the options of the file: name=@avatar.png;type=image/png;filename=me.png
+func parseFormField(value string) (models.MultipartField, error) {
+	name, content, _ := strings.Cut(value, "=")
+	field := models.MultipartField{Name: name}
+
+	switch {
+	case strings.HasPrefix(content, "<"):
+		return field, fmt.Errorf("form field '%s' reads its value from a file, which is not supported", name)
+	case strings.HasPrefix(content, "@"):
+		options := strings.Split(content[1:], ";")
+		field.File = options[0]
+
+		for _, option := range options[1:] {
+			key, optionValue, _ := strings.Cut(option, "=")
+			switch strings.TrimSpace(key) {
+			case "type":
+				field.ContentType = optionValue
+			case "filename":
+				field.Filename = optionValue
+			}
+		}
+	default:
+		field.Value = content
+	}
+
+	return field, nil
+}
+
+// urlEncodeData encodes data the way curl does for --data-urlencode.
+func urlEncodeData(data string) string {
+	if name, value, ok := strings.Cut(data, "="); ok {
+		if name == "" {
+			return url.QueryEscape(value)
+		}
+		return name + "=" + url.QueryEscape(value)
+	}
+
+	return url.QueryEscape(data)
+}
+
+// isFlagGroup reports whether the argument is a group of short options
+// without a value, like -sSL.
+func isFlagGroup(arg string) bool {
+	for _, r := range arg[1:] {
+		if !flagsWithoutValue["-"+string(r)] && r != 'G' && r != 'I' && r != 'N' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Split splits a command line into arguments the way a POSIX shell would, supporting
+// single quotes, double quotes, ANSI-C quotes ($'...') and line continuations.
+func Split(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+	)
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+
+		switch {
+		case c == '\\' && i+1 < len(command):
+			i++
+			if command[i] == '\n' {
+				continue
+			}
+			if command[i] == '\r' && i+1 < len(command) && command[i+1] == '\n' {
+				i++
+				continue
+			}
+			current.WriteByte(command[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end == -1 {
+				return nil, ErrUnterminatedQuote
+			}
+			current.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+			value, n, err := readANSICQuoted(command[i+2:])
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(value)
+			i += n + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.ContainsRune("\"\\$`\n", rune(command[i+1])) {
+					i++
+					if command[i] == '\n' {
+						continue
+					}
+				}
+				current.WriteByte(command[i])
+			}
+			if i >= len(command) {
+				return nil, ErrUnterminatedQuote
+			}
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// readANSICQuoted reads the content of a $'...' quote, s starts right after the opening
+// quote. Returns the value along with the number of bytes read, including the closing quote.
+func readANSICQuoted(s string) (string, int, error) {
+	var value strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '\'' {
+			return value.String(), i + 1, nil
+		}
+
+		if c != '\\' || i+1 >= len(s) {
+			value.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n':
+			value.WriteByte('\n')
+		case 't':
+			value.WriteByte('\t')
+		case 'r':
+			value.WriteByte('\r')
+		case 'u', 'x':
+			size := 4
+			if s[i] == 'x' {
+				size = 2
+			}
+
+			var r rune
+			if i+size < len(s) {
+				_, err := fmt.Sscanf(s[i+1:i+1+size], "%x", &r)
+				if err == nil {
+					value.WriteRune(r)
+					i += size
+					continue
+				}
+			}
+			value.WriteByte('\\')
+			value.WriteByte(s[i])
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+
+	return "", 0, ErrUnterminatedQuote
+}
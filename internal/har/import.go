@@ -0,0 +1,195 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// ignoredHeaders are set by the browser, or the http client, on their own.
+var ignoredHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"transfer-encoding": true,
+}
+
+// Parse converts the requests in the HAR log to requests, in the order they were made.
+// When host is set only requests sent to it are converted. Requests with the same
+// method and url, ignoring the query, are only converted once.
+func Parse(r io.Reader, host string) ([]models.HttpRequestTemplate, error) {
+	var archive Archive
+
+	err := json.NewDecoder(r).Decode(&archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []models.HttpRequestTemplate
+	seen := make(map[string]bool)
+	names := make(map[string]bool)
+
+	for i, entry := range archive.Log.Entries {
+		reqURL, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+
+		if host != "" && reqURL.Hostname() != host && reqURL.Host != host {
+			continue
+		}
+
+		query := reqURL.Query()
+		reqURL.RawQuery = ""
+		reqURL.Fragment = ""
+
+		key := entry.Request.Method + " " + reqURL.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		request := convertRequest(entry.Request, reqURL, query)
+
+		// Requests to the same route with different methods or hosts are numbered.
+		request.Name = models.RouteName(request.Method, request.URL)
+		for n, base := 2, request.Name; names[request.Name]; n++ {
+			request.Name = fmt.Sprintf("%s%d", base, n)
+		}
+		names[request.Name] = true
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+func convertRequest(r Request, reqURL *url.URL, query url.Values) models.HttpRequestTemplate {
+	request := models.HttpRequestTemplate{
+		Method: strings.ToUpper(r.Method),
+		URL:    reqURL.String(),
+	}
+
+	if len(query) > 0 {
+		request.Query = make(map[string]string, len(query))
+		for key := range query {
+			request.Query[key] = query.Get(key)
+		}
+	}
+
+	for _, header := range r.Headers {
+		name := strings.ToLower(header.Name)
+
+		// HTTP/2 pseudo headers like :authority and the fetch metadata headers
+		if strings.HasPrefix(name, ":") || strings.HasPrefix(name, "sec-") || ignoredHeaders[name] {
+			continue
+		}
+
+		if request.Headers == nil {
+			request.Headers = make(map[string]string)
+		}
+		request.Headers[header.Name] = header.Value
+	}
+
+	if r.PostData != nil && (r.PostData.Text != "" || len(r.PostData.Params) > 0) {
+		mimeType := strings.ToLower(r.PostData.MimeType)
+
+		if strings.Contains(mimeType, "json") {
+			request.JsonBody = models.JSONBody{Text: r.PostData.Text}
+
+			// jsonBody sets the content type on its own
+			if mimeType == "application/json" {
+				for key := range request.Headers {
+					if strings.EqualFold(key, "Content-Type") {
+						delete(request.Headers, key)
+					}
+				}
+			}
+		} else if strings.Contains(mimeType, "xml") {
+			request.XmlBody = r.PostData.Text
+
+			// xmlBody sets the content type on its own
+			if mimeType == "application/xml" {
+				for key := range request.Headers {
+					if strings.EqualFold(key, "Content-Type") {
+						delete(request.Headers, key)
+					}
+				}
+			}
+		} else if form, ok := formBody(r.PostData); ok {
+			request.FormBody = form
+
+			// formBody sets the content type on its own
+			for key := range request.Headers {
+				if strings.EqualFold(key, "Content-Type") {
+					delete(request.Headers, key)
+				}
+			}
+		} else if fields, ok := multipartFields(r.PostData); ok {
+			request.Multipart = fields
+
+			// multipart sets the content type, along with the boundary, on its own
+			for key := range request.Headers {
+				if strings.EqualFold(key, "Content-Type") {
+					delete(request.Headers, key)
+				}
+			}
+		} else {
+			request.Body = r.PostData.Text
+		}
+	}
+
+	return request
+}
+
+// formBody returns the form of the url encoded post data, from its params or its text.
+func formBody(postData *PostData) (models.FormBody, bool) {
+	if !strings.HasPrefix(strings.ToLower(postData.MimeType), "application/x-www-form-urlencoded") {
+		return nil, false
+	}
+
+	if len(postData.Params) > 0 {
+		form := models.FormBody{}
+		for _, param := range postData.Params {
+			form[param.Name] = append(form[param.Name], param.Value)
+		}
+
+		return form, true
+	}
+
+	values, err := url.ParseQuery(postData.Text)
+	if err != nil {
+		return nil, false
+	}
+
+	return models.NewFormBody(values), true
+}
+
+// multipartFields returns the fields of the multipart post data from its params. The content
+// of files is not part of a HAR, files are expected next to the request file.
+func multipartFields(postData *PostData) ([]models.MultipartField, bool) {
+	if !strings.HasPrefix(strings.ToLower(postData.MimeType), "multipart/form-data") || len(postData.Params) == 0 {
+		return nil, false
+	}
+
+	fields := make([]models.MultipartField, 0, len(postData.Params))
+	for _, param := range postData.Params {
+		if param.FileName == "" {
+			fields = append(fields, models.MultipartField{Name: param.Name, Value: param.Value})
+			continue
+		}
+
+		fields = append(fields, models.MultipartField{
+			Name:        param.Name,
+			File:        param.FileName,
+			ContentType: param.ContentType,
+		})
+	}
+
+	return fields, true
+}
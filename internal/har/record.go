@@ -0,0 +1,207 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+// Recorder records the requests sent, along with their responses, as entries of a HAR log.
+type Recorder struct {
+	log Log
+}
+
+func NewRecorder(creatorVersion string) *Recorder {
+	return &Recorder{
+		log: Log{
+			Version: "1.2",
+			Creator: Creator{Name: "yurl", Version: creatorVersion},
+			Entries: []Entry{},
+		},
+	}
+}
+
+// Record adds the response, and the request it was sent for, to the log. Websockets are
+// recorded as their handshake, along with the messages sent and received.
+func (r *Recorder) Record(response *models.HttpResponse) error {
+	httpReq := response.Request.RawRequest
+	httpResp := response.RawResponse
+	webSocket := response.Request.Template.WebSocket != nil
+
+	requestURL := *httpReq.URL
+	if webSocket {
+		// The handshake response is not kept, it switched protocols as the websocket was opened
+		httpResp = &http.Response{StatusCode: http.StatusSwitchingProtocols, Proto: "HTTP/1.1", Header: http.Header{}}
+
+		switch requestURL.Scheme {
+		case "http":
+			requestURL.Scheme = "ws"
+		case "https":
+			requestURL.Scheme = "wss"
+		}
+	}
+
+	request := Request{
+		Method:      httpReq.Method,
+		URL:         requestURL.String(),
+		HTTPVersion: httpReq.Proto,
+		Cookies:     []NameValue{},
+		Headers:     nameValues(httpReq.Header),
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+
+	for key, values := range httpReq.URL.Query() {
+		for _, value := range values {
+			request.QueryString = append(request.QueryString, NameValue{Name: key, Value: value})
+		}
+	}
+	sortNameValues(request.QueryString)
+
+	if multipart := response.Request.Template.Multipart; len(multipart) > 0 {
+		// Multipart forms are streamed and can't be read again, only the fields are recorded
+		request.BodySize = int(httpReq.ContentLength)
+		request.PostData = &PostData{MimeType: httpReq.Header.Get("Content-Type")}
+
+		for _, field := range multipart {
+			request.PostData.Params = append(request.PostData.Params, Param{
+				Name:        field.Name,
+				Value:       field.Value,
+				FileName:    field.Filename,
+				ContentType: field.ContentType,
+			})
+		}
+	} else if httpReq.GetBody != nil {
+		body, err := httpReq.GetBody()
+		if err != nil {
+			return err
+		}
+
+		content, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+
+		if len(content) > 0 {
+			request.BodySize = len(content)
+			request.PostData = &PostData{
+				MimeType: httpReq.Header.Get("Content-Type"),
+				Text:     string(content),
+			}
+		}
+	}
+
+	for _, cookie := range httpReq.Cookies() {
+		request.Cookies = append(request.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	content := Content{
+		Size:     len(response.RawBody),
+		MimeType: httpResp.Header.Get("Content-Type"),
+	}
+
+	// Bodies saved to a file are not kept in memory, only their size is recorded
+	if response.SavedTo != "" {
+		content.Size = int(response.SavedSize)
+	}
+
+	if utf8.Valid(response.RawBody) {
+		content.Text = string(response.RawBody)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(response.RawBody)
+		content.Encoding = "base64"
+	}
+
+	responseCookies := []NameValue{}
+	for _, cookie := range httpResp.Cookies() {
+		responseCookies = append(responseCookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	milliseconds := float64(response.Duration) / float64(time.Millisecond)
+
+	entry := Entry{
+		StartedDateTime: response.StartedAt.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            milliseconds,
+		Request:         request,
+		Response: Response{
+			Status:      httpResp.StatusCode,
+			StatusText:  http.StatusText(httpResp.StatusCode),
+			HTTPVersion: httpResp.Proto,
+			Cookies:     responseCookies,
+			Headers:     nameValues(httpResp.Header),
+			Content:     content,
+			RedirectURL: httpResp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    content.Size,
+		},
+		Timings: Timings{Wait: milliseconds},
+	}
+
+	if webSocket {
+		entry.ResourceType = "websocket"
+		entry.WebSocketMessages = webSocketMessages(response.Messages)
+	}
+
+	r.log.Entries = append(r.log.Entries, entry)
+
+	return nil
+}
+
+// webSocketMessages converts the messages to the messages of the entry, as text frames.
+func webSocketMessages(messages []models.WebSocketMessage) []WebSocketMessage {
+	entryMessages := make([]WebSocketMessage, 0, len(messages))
+
+	for _, message := range messages {
+		messageType := "receive"
+		if message.Sent {
+			messageType = "send"
+		}
+
+		entryMessages = append(entryMessages, WebSocketMessage{
+			Type:   messageType,
+			Time:   float64(message.Time.UnixNano()) / float64(time.Second),
+			Opcode: 1,
+			Data:   message.Data,
+		})
+	}
+
+	return entryMessages
+}
+
+// WriteFile writes the log to the file at path.
+func (r *Recorder) WriteFile(path string) error {
+	content, err := json.MarshalIndent(Archive{Log: r.log}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+func nameValues(header http.Header) []NameValue {
+	values := []NameValue{}
+
+	for name, headerValues := range header {
+		for _, value := range headerValues {
+			values = append(values, NameValue{Name: name, Value: value})
+		}
+	}
+	sortNameValues(values)
+
+	return values
+}
+
+func sortNameValues(values []NameValue) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+}
@@ -0,0 +1,402 @@
+// Package httpfile parses request files in the .http (or .rest) format used by
+// VS Code REST Client and JetBrains HTTP Client.
+package httpfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+var (
+	// fileVariableRegex matches file variables: @name = value
+	fileVariableRegex = regexp.MustCompile(`^@([^\s=]+)\s*=\s*(.*)$`)
+
+	// nameRegex matches the name of a request: # @name Login or // @name Login
+	nameRegex = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\S+)`)
+
+	// requestLineRegex matches the request line: GET https://example.com HTTP/1.1
+	requestLineRegex = regexp.MustCompile(`^([A-Z]+)\s+(\S+)(?:\s+HTTP/[\d.]+)?$`)
+
+	placeholderRegex = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+	// responseVariableRegex matches values from the response of another request: login.response.body.$.token
+	responseVariableRegex = regexp.MustCompile(`^([\w-]+)\.response\.body\.(\$.*)$`)
+)
+
+// Parse parses all the requests in the .http file into a http template. File variables
+// become template variables and values used from responses of other requests become
+// exports of those requests, which are added as pre-requests.
+func Parse(r io.Reader) (*models.HttpTemplate, error) {
+	template := &models.HttpTemplate{
+		Variables: make(map[string]string),
+		Requests:  make(map[string]models.HttpRequestTemplate),
+	}
+
+	blocks, err := splitBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []string
+
+	for _, block := range blocks {
+		request, ok, err := parseBlock(block, template.Variables)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if request.Name == "" {
+			request.Name = fmt.Sprintf("Request%d", len(order)+1)
+		}
+
+		if _, ok := template.Requests[request.Name]; ok {
+			return nil, fmt.Errorf("line %d: request '%s' is already defined", block.line, request.Name)
+		}
+
+		template.Requests[request.Name] = request
+		order = append(order, request.Name)
+	}
+
+	expandFileVariables(template.Variables)
+
+	for _, name := range order {
+		request := template.Requests[name]
+
+		err := convertPlaceholders(&request, template)
+		if err != nil {
+			return nil, err
+		}
+
+		template.Requests[name] = request
+	}
+
+	return template, nil
+}
+
+// block is a part of the file between two ### separators.
+type block struct {
+	title string
+	line  int
+	lines []string
+}
+
+func splitBlocks(r io.Reader) ([]block, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	blocks := []block{{line: 1}}
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(line, "###") {
+			blocks = append(blocks, block{
+				title: strings.TrimSpace(strings.TrimLeft(line, "#")),
+				line:  lineNumber,
+			})
+			continue
+		}
+
+		current := &blocks[len(blocks)-1]
+		current.lines = append(current.lines, line)
+	}
+
+	return blocks, scanner.Err()
+}
+
+// parseBlock parses the request in the block, file variables found
+// in the block are added to vars.
+func parseBlock(b block, vars map[string]string) (models.HttpRequestTemplate, bool, error) {
+	request := models.HttpRequestTemplate{
+		Description: b.title,
+	}
+
+	i := 0
+
+	// Comments, metadata and file variables before the request line
+	for ; i < len(b.lines); i++ {
+		line := strings.TrimSpace(b.lines[i])
+
+		if match := nameRegex.FindStringSubmatch(line); match != nil {
+			request.Name = match[1]
+			continue
+		}
+
+		if match := fileVariableRegex.FindStringSubmatch(line); match != nil {
+			vars[variable.NormalizeKey(match[1])] = match[2]
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		break
+	}
+
+	if i == len(b.lines) {
+		return request, false, nil
+	}
+
+	requestLine := strings.TrimSpace(b.lines[i])
+	i++
+
+	// Query can continue on the following lines: ?page=1 or &limit=10
+	for ; i < len(b.lines); i++ {
+		line := strings.TrimSpace(b.lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		requestLine += line
+	}
+
+	request.Method = "GET"
+	target := requestLine
+	if match := requestLineRegex.FindStringSubmatch(requestLine); match != nil {
+		request.Method = match[1]
+		target = match[2]
+	}
+
+	// Headers until the first empty line
+	headers := make(map[string]string)
+	for ; i < len(b.lines); i++ {
+		line := strings.TrimSpace(b.lines[i])
+		if line == "" {
+			i++
+			break
+		}
+
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return request, false, fmt.Errorf("line %d: invalid header '%s'", b.line+i+1, line)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	if len(headers) > 0 {
+		request.Headers = headers
+	}
+
+	err := setTarget(&request, target, headers)
+	if err != nil {
+		return request, false, fmt.Errorf("line %d: %w", b.line, err)
+	}
+
+	// Rest of the block is the body
+	bodyLines := b.lines[min(i, len(b.lines)):]
+	for len(bodyLines) > 0 && strings.TrimSpace(bodyLines[len(bodyLines)-1]) == "" {
+		bodyLines = bodyLines[:len(bodyLines)-1]
+	}
+
+	body := strings.Join(bodyLines, "\n")
+
+	// Body read from a file, <@ replaces the variables in its content
+	if strings.HasPrefix(body, "<") {
+		if len(bodyLines) > 1 {
+			return request, false, fmt.Errorf("line %d: request bodies mixing files and text are not supported", b.line)
+		}
+
+		template := strings.HasPrefix(body, "<@")
+		path := strings.TrimSpace(strings.TrimLeft(body, "<@"))
+
+		request.BodyFile = &models.BodyFile{Path: path, Template: template}
+
+		return request, true, nil
+	}
+
+	if body != "" {
+		contentType := strings.ToLower(headerValue(headers, "Content-Type"))
+
+		switch {
+		case strings.Contains(contentType, "json"):
+			request.JsonBody = models.JSONBody{Text: body}
+		case strings.Contains(contentType, "xml"):
+			request.XmlBody = body
+		default:
+			request.Body = body
+		}
+	}
+
+	return request, true, nil
+}
+
+// setTarget sets either the path or the absolute url of the request.
+func setTarget(request *models.HttpRequestTemplate, target string, headers map[string]string) error {
+	if !strings.HasPrefix(target, "/") {
+		if !strings.Contains(target, "://") && !strings.HasPrefix(target, "{{") {
+			target = "http://" + target
+		}
+
+		request.URL = target
+		return nil
+	}
+
+	// Relative targets are sent to the Host header, if there is one.
+	if host := headerValue(headers, "Host"); host != "" {
+		request.URL = "http://" + host + target
+		return nil
+	}
+
+	path, rawQuery, _ := strings.Cut(target, "?")
+	request.Path = path
+
+	if rawQuery != "" {
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return err
+		}
+
+		request.Query = make(map[string]string, len(query))
+		for key := range query {
+			request.Query[key] = query.Get(key)
+		}
+	}
+
+	return nil
+}
+
+// systemVariable converts the system variable to the placeholder calling the same function:
+// {{$guid}}, {{$timestamp}} and {{$randomInt min max}}. The placeholder is returned as it is
+// when there is no equivalent.
+func systemVariable(name string, placeholder string) string {
+	fields := strings.Fields(name)
+
+	switch {
+	case len(fields) == 1 && fields[0] == "$guid":
+		return "{{ uuid }}"
+	case len(fields) == 1 && fields[0] == "$timestamp":
+		return "{{ now | unix }}"
+	case len(fields) == 3 && fields[0] == "$randomInt":
+		// The max of $randomInt is excluded
+		low, lowErr := strconv.Atoi(fields[1])
+		high, highErr := strconv.Atoi(fields[2])
+		if lowErr == nil && highErr == nil {
+			return fmt.Sprintf("{{ randomInt %d %d }}", low, high-1)
+		}
+	}
+
+	return placeholder
+}
+
+// convertPlaceholders converts {{name}} placeholders to yurl variables. Placeholders
+// reading from the response of another request add that request as a pre-request
+// and export the value from it.
+func convertPlaceholders(request *models.HttpRequestTemplate, template *models.HttpTemplate) error {
+	var convertErr error
+
+	convert := func(s string) string {
+		return placeholderRegex.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := placeholderRegex.FindStringSubmatch(placeholder)[1]
+
+			// System variables without an equivalent function, like {{$dotenv name}}, are left as they are
+			if strings.HasPrefix(name, "$") {
+				return systemVariable(name, placeholder)
+			}
+
+			match := responseVariableRegex.FindStringSubmatch(name)
+			if match == nil {
+				return "{{ " + variable.NormalizeKey(name) + " }}"
+			}
+
+			sourceName, jsonPath := match[1], match[2]
+
+			source, ok := template.Requests[sourceName]
+			if !ok {
+				convertErr = fmt.Errorf("'%s' uses the response of '%s' which is not defined", request.Name, sourceName)
+				return placeholder
+			}
+
+			key := variable.NormalizeKey(sourceName + " " + jsonPath)
+
+			if source.Exports == nil {
+				source.Exports = make(map[string]models.Export)
+			}
+			source.Exports[key] = models.Export{JSON: jsonPath}
+			template.Requests[sourceName] = source
+
+			preRequest := models.PreRequest{Name: sourceName}
+			if sourceName != request.Name && !slices.Contains(request.PreRequests, preRequest) {
+				request.PreRequests = append(request.PreRequests, preRequest)
+			}
+
+			return "{{ " + key + " }}"
+		})
+	}
+
+	request.URL = convert(request.URL)
+	request.Path = convert(request.Path)
+	request.Body = convert(request.Body)
+	request.JsonBody.Text = convert(request.JsonBody.Text)
+	request.XmlBody = convert(request.XmlBody)
+
+	if request.BodyFile != nil {
+		request.BodyFile.Path = convert(request.BodyFile.Path)
+	}
+
+	for key, value := range request.Headers {
+		request.Headers[key] = convert(value)
+	}
+
+	for key, value := range request.Query {
+		request.Query[key] = convert(value)
+	}
+
+	return convertErr
+}
+
+// expandFileVariables replaces file variables used in the value of other file
+// variables, for example: @baseUrl = https://{{host}}/api
+func expandFileVariables(vars map[string]string) {
+	// Each pass resolves one level of nesting, limited so that cycles terminate.
+	for range len(vars) {
+		changed := false
+
+		for key, value := range vars {
+			expanded := placeholderRegex.ReplaceAllStringFunc(value, func(placeholder string) string {
+				name := variable.NormalizeKey(placeholderRegex.FindStringSubmatch(placeholder)[1])
+				if nested, ok := vars[name]; ok && name != key {
+					return nested
+				}
+				return placeholder
+			})
+
+			if expanded != value {
+				vars[key] = expanded
+				changed = true
+			}
+		}
+
+		if !changed {
+			return
+		}
+	}
+}
+
+// headerValue returns the value of the header, ignoring the case of its name.
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
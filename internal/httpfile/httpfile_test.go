@@ -0,0 +1,142 @@
+package httpfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+func TestParse(t *testing.T) {
+	file := `@baseUrl = https://{{host}}/api
+@host = example.com
+
+### Log in
+# @name login
+POST {{baseUrl}}/login HTTP/1.1
+Content-Type: application/json
+
+{"user": "{{user_name}}"}
+
+###
+# @name me
+GET {{baseUrl}}/me
+    ?verbose=true
+Authorization: Bearer {{login.response.body.$.token}}
+X-Request-Id: {{$guid}}
+X-Unknown: {{$dotenv HOME}}
+
+###
+GET /health?full=1
+
+###
+PUT /upload
+Content-Type: application/octet-stream
+
+<@ ./payload.bin
+`
+
+	template, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantVariables := map[string]string{
+		"baseUrl": "https://example.com/api",
+		"host":    "example.com",
+	}
+	if !reflect.DeepEqual(template.Variables, wantVariables) {
+		t.Errorf("Variables = %v, want %v", template.Variables, wantVariables)
+	}
+
+	wantRequests := map[string]models.HttpRequestTemplate{
+		"login": {
+			Name:        "login",
+			Description: "Log in",
+			Method:      "POST",
+			URL:         "{{ baseUrl }}/login",
+			Headers:     map[string]string{"Content-Type": "application/json"},
+			JsonBody:    models.JSONBody{Text: `{"user": "{{ userName }}"}`},
+			Exports:     map[string]models.Export{"loginToken": {JSON: "$.token"}},
+		},
+		"me": {
+			Name:   "me",
+			Method: "GET",
+			URL:    "{{ baseUrl }}/me?verbose=true",
+			Headers: map[string]string{
+				"Authorization": "Bearer {{ loginToken }}",
+				"X-Request-Id":  "{{ uuid }}",
+				"X-Unknown":     "{{$dotenv HOME}}",
+			},
+			PreRequests: []models.PreRequest{{Name: "login"}},
+		},
+		"Request3": {
+			Name:   "Request3",
+			Method: "GET",
+			Path:   "/health",
+			Query:  map[string]string{"full": "1"},
+		},
+		"Request4": {
+			Name:     "Request4",
+			Method:   "PUT",
+			Path:     "/upload",
+			Headers:  map[string]string{"Content-Type": "application/octet-stream"},
+			BodyFile: &models.BodyFile{Path: "./payload.bin", Template: true},
+		},
+	}
+
+	for name, want := range wantRequests {
+		got, ok := template.Requests[name]
+		if !ok {
+			t.Errorf("request %s not found", name)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("request %s = %+v, want %+v", name, got, want)
+		}
+	}
+
+	if len(template.Requests) != len(wantRequests) {
+		t.Errorf("got %d requests, want %d", len(template.Requests), len(wantRequests))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{
+			name:    "duplicate name",
+			file:    "# @name a\nGET /a\n\n###\n# @name a\nGET /b\n",
+			wantErr: "request 'a' is already defined",
+		},
+		{
+			name:    "invalid header",
+			file:    "GET /a\nnot a header\n",
+			wantErr: "invalid header 'not a header'",
+		},
+		{
+			name:    "response of an unknown request",
+			file:    "GET /a?token={{missing.response.body.$.token}}\n",
+			wantErr: "uses the response of 'missing' which is not defined",
+		},
+		{
+			name:    "file mixed with text",
+			file:    "POST /a\n\n< ./a.json\nmore\n",
+			wantErr: "mixing files and text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
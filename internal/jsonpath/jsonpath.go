@@ -0,0 +1,67 @@
+// Package jsonpath splits simple JSONPaths, made of names and indexes, into their
+// segments so that they can be converted to code.
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupported = errors.New("only names and indexes are supported")
+
+// Segments splits the JSONPath into its segments, names are strings and indexes are
+// ints, for example: $.data.items[0]['first-name'] becomes data, items, 0, first-name.
+func Segments(path string) ([]any, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath '%s' must start with $", path)
+	}
+
+	var segments []any
+	rest := path[1:]
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, fmt.Errorf("JSONPath '%s': %w", path, ErrUnsupported)
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+
+			name := rest[1 : end+1]
+			if name == "" || name == "*" {
+				return nil, fmt.Errorf("JSONPath '%s': %w", path, ErrUnsupported)
+			}
+
+			segments = append(segments, name)
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("JSONPath '%s' has an unterminated [", path)
+			}
+
+			selector := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+				segments = append(segments, index)
+				continue
+			}
+
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				segments = append(segments, selector[1:len(selector)-1])
+				continue
+			}
+
+			return nil, fmt.Errorf("JSONPath '%s': %w", path, ErrUnsupported)
+		default:
+			return nil, fmt.Errorf("JSONPath '%s' is invalid", path)
+		}
+	}
+
+	return segments, nil
+}
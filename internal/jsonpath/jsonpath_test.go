@@ -0,0 +1,53 @@
+package jsonpath
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []any
+		wantErr string
+	}{
+		{path: "$", want: nil},
+		{path: "$.token", want: []any{"token"}},
+		{path: "$.data.items[0].name", want: []any{"data", "items", 0, "name"}},
+		{path: "$[1][2]", want: []any{1, 2}},
+		{path: "$.data['first-name']", want: []any{"data", "first-name"}},
+		{path: `$["a.b"].c`, want: []any{"a.b", "c"}},
+		{path: "$[ 3 ]", want: []any{3}},
+		{path: "token", wantErr: "must start with $"},
+		{path: "$.items[0", wantErr: "unterminated ["},
+		{path: "$x", wantErr: "is invalid"},
+	}
+
+	for _, tt := range tests {
+		got, err := Segments(tt.path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Segments(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Segments(%q) error = %v", tt.path, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Segments(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSegmentsUnsupported(t *testing.T) {
+	for _, path := range []string{"$..name", "$.items[*]", "$.*", "$.items[-1]", "$.items[?(@.id)]", "$.items[0:2]"} {
+		if _, err := Segments(path); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Segments(%q) error = %v, want %v", path, err, ErrUnsupported)
+		}
+	}
+}
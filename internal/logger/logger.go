@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
@@ -39,38 +40,66 @@ func LogHttpRequest(ctx context.Context, request *models.HttpRequest) {
 	completeUrl := styles.Url.Render(request.RawRequest.URL.String())
 	fmt.Printf("%s %s %s\n", method, completeUrl, protocol)
 
+	if socket := request.Template.Socket; socket != "" {
+		fmt.Printf("%s %s\n", styles.SecondaryText.Render("via"), styles.Url.Render(socket))
+	}
+
 	for headerName, headerValue := range request.RawRequest.Header {
 		fmt.Printf("%s: %s\n", styles.HeaderName.Render(headerName), strings.Join(headerValue, ";"))
 	}
 
-	fmt.Println(request.Template.JsonBody)
+	fmt.Println(request.Template.JsonBody.Text)
 }
 
 // LogResponse logs the response to the console.
 func LogHttpResponse(ctx context.Context, httpResponse *models.HttpResponse) {
+	LogHttpResponseHead(ctx, httpResponse.RawResponse)
+
+	// Body
+	if httpResponse.IsBinary() {
+		fmt.Printf("\nBinary body of %d bytes\n", len(httpResponse.RawBody))
+	} else {
+		fmt.Println("\n" + string(httpResponse.RawBody))
+	}
+
+	LogExports(ctx, httpResponse.Exports)
+}
+
+// LogHttpResponseHead logs the status and the headers of the response.
+func LogHttpResponseHead(ctx context.Context, response *http.Response) {
 	fmt.Println(styles.SectionHeader.Render("Response"))
 
-	protocol := styles.Url.Render(httpResponse.RawResponse.Proto)
-	status := styles.Url.Render(httpResponse.RawResponse.Status)
+	protocol := styles.Url.Render(response.Proto)
+	status := styles.Url.Render(response.Status)
 	fmt.Println(protocol, status)
 
 	// Headers
-	if len(httpResponse.RawResponse.Header) > 0 {
+	if len(response.Header) > 0 {
 		fmt.Println()
 	}
-	for key, value := range httpResponse.RawResponse.Header {
+	for key, value := range response.Header {
 		fmt.Printf("%s: %s\n", styles.HeaderName.Render(key), strings.Join(value, "; "))
 	}
+}
 
-	// Body
-	fmt.Println("\n" + string(httpResponse.RawBody))
+// LogWebSocketMessage logs a message sent, or received, on a websocket.
+func LogWebSocketMessage(ctx context.Context, sent bool, message string) {
+	direction := "<"
+	if sent {
+		direction = ">"
+	}
+
+	fmt.Printf("%s %s\n", styles.Url.Render(direction), message)
+}
 
+// LogExports logs the values exported from the response.
+func LogExports(ctx context.Context, exports map[string]any) {
 	fmt.Println(styles.SectionHeader.Render("Exports"))
 
-	if len(httpResponse.Exports) == 0 {
+	if len(exports) == 0 {
 		fmt.Println("  No exports")
 	}
-	for key, value := range httpResponse.Exports {
+	for key, value := range exports {
 		key = styles.SecondaryText.Copy().Bold(true).Render(key)
 		value = styles.PrimaryText.Render(fmt.Sprintf("%v", value))
 
@@ -0,0 +1,624 @@
+// Package openapi converts OpenAPI 3.x documents to requests.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedVersion = errors.New("only OpenAPI 3.x documents are supported")
+
+// methods are the operations of a path item, in the order requests are created.
+var methods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+type document struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Servers    []server            `yaml:"servers"`
+	Paths      map[string]pathItem `yaml:"paths"`
+	Components components          `yaml:"components"`
+}
+
+type server struct {
+	URL         string                    `yaml:"url"`
+	Description string                    `yaml:"description"`
+	Variables   map[string]serverVariable `yaml:"variables"`
+}
+
+type serverVariable struct {
+	Default string `yaml:"default"`
+}
+
+type pathItem struct {
+	Parameters []parameter `yaml:"parameters"`
+	Operations map[string]operation
+}
+
+func (p *pathItem) UnmarshalYAML(node *yaml.Node) error {
+	var item struct {
+		Parameters []parameter `yaml:"parameters"`
+	}
+	if err := node.Decode(&item); err != nil {
+		return err
+	}
+
+	var operations map[string]yaml.Node
+	if err := node.Decode(&operations); err != nil {
+		return err
+	}
+
+	p.Parameters = item.Parameters
+	p.Operations = make(map[string]operation)
+
+	for _, method := range methods {
+		operationNode, ok := operations[method]
+		if !ok {
+			continue
+		}
+
+		var op operation
+		if err := operationNode.Decode(&op); err != nil {
+			return err
+		}
+		p.Operations[method] = op
+	}
+
+	return nil
+}
+
+type operation struct {
+	OperationID string       `yaml:"operationId"`
+	Summary     string       `yaml:"summary"`
+	Parameters  []parameter  `yaml:"parameters"`
+	RequestBody *requestBody `yaml:"requestBody"`
+}
+
+type parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *schema `yaml:"schema"`
+}
+
+type requestBody struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema   *schema            `yaml:"schema"`
+	Example  any                `yaml:"example"`
+	Examples map[string]example `yaml:"examples"`
+}
+
+type example struct {
+	Ref   string `yaml:"$ref"`
+	Value any    `yaml:"value"`
+}
+
+type schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       schemaType         `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Properties map[string]*schema `yaml:"properties"`
+	Items      *schema            `yaml:"items"`
+	Example    any                `yaml:"example"`
+	Examples   []any              `yaml:"examples"`
+	Default    any                `yaml:"default"`
+	Enum       []any              `yaml:"enum"`
+	AllOf      []*schema          `yaml:"allOf"`
+	OneOf      []*schema          `yaml:"oneOf"`
+	AnyOf      []*schema          `yaml:"anyOf"`
+}
+
+// schemaType is the type of a schema, which is a list of types since OpenAPI 3.1.
+type schemaType []string
+
+func (t *schemaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaType{node.Value}
+		return nil
+	}
+
+	var types []string
+	if err := node.Decode(&types); err != nil {
+		return err
+	}
+	*t = types
+
+	return nil
+}
+
+// is reports whether the schema is of the type, ignoring null.
+func (t schemaType) is(name string) bool {
+	for _, value := range t {
+		if value != "null" {
+			return value == name
+		}
+	}
+
+	return false
+}
+
+type components struct {
+	Schemas       map[string]*schema     `yaml:"schemas"`
+	Parameters    map[string]parameter   `yaml:"parameters"`
+	RequestBodies map[string]requestBody `yaml:"requestBodies"`
+	Examples      map[string]example     `yaml:"examples"`
+}
+
+// Parse converts every operation of the OpenAPI document, in yaml or json, to a request.
+//
+// The first server becomes the config of the template and the other servers become its
+// environments. Path, query and header parameters become placeholders, only required
+// query and header parameters are added. Examples of the request body, or a sample
+// made from its schema, fill the body.
+func Parse(r io.Reader) (*models.HttpTemplate, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Documents in json can use tabs for indentation, which yaml doesn't allow.
+	if trimmed := strings.TrimSpace(string(content)); strings.HasPrefix(trimmed, "{") {
+		var value any
+		if err := json.Unmarshal(content, &value); err != nil {
+			return nil, err
+		}
+
+		content, err = yaml.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var doc document
+
+	err = yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, ErrUnsupportedVersion
+	}
+
+	template := &models.HttpTemplate{
+		Requests: make(map[string]models.HttpRequestTemplate),
+	}
+
+	template.Config, err = doc.config()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := doc.Paths[path]
+
+		for _, method := range methods {
+			op, ok := item.Operations[method]
+			if !ok {
+				continue
+			}
+
+			request, err := doc.request(path, method, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+
+			if _, ok := template.Requests[request.Name]; ok {
+				return nil, fmt.Errorf("%s %s: request '%s' is already defined", strings.ToUpper(method), path, request.Name)
+			}
+
+			template.Requests[request.Name] = request
+		}
+	}
+
+	return template, nil
+}
+
+// config makes the config from the servers of the document, servers with a
+// relative url are sent to localhost.
+func (doc *document) config() (models.Config, error) {
+	config := models.Config{Host: "localhost"}
+
+	for i, s := range doc.Servers {
+		service, err := s.service()
+		if err != nil {
+			return config, err
+		}
+
+		if i == 0 {
+			config.Host = service.Host
+			config.Port = service.Port
+			config.Scheme = service.Scheme
+			config.BasePath = service.BasePath
+			continue
+		}
+
+		name := variable.NormalizeKey(s.Description)
+		if name == "" {
+			name = "server" + strconv.Itoa(i+1)
+		}
+		name = strings.ToLower(name[:1]) + name[1:]
+
+		if config.Environments == nil {
+			config.Environments = make(map[string]models.Environment)
+		}
+
+		// An empty base path keeps the one of the config, / removes it
+		basePath := service.BasePath
+		switch {
+		case basePath == config.BasePath:
+			basePath = ""
+		case basePath == "":
+			basePath = "/"
+		}
+
+		config.Environments[name] = models.Environment{
+			Host:     service.Host,
+			Port:     service.Port,
+			Scheme:   service.Scheme,
+			BasePath: basePath,
+		}
+	}
+
+	return config, nil
+}
+
+// service converts the url of the server, with its variables replaced by their defaults.
+func (s server) service() (models.Service, error) {
+	rawURL := s.URL
+	for name, v := range s.Variables {
+		rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", v.Default)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return models.Service{}, fmt.Errorf("invalid server url '%s': %w", s.URL, err)
+	}
+
+	service := models.Service{
+		Host:     u.Hostname(),
+		Scheme:   u.Scheme,
+		BasePath: strings.TrimSuffix(u.Path, "/"),
+	}
+
+	if service.Host == "" {
+		service.Host = "localhost"
+	}
+
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		service.Port = port
+	}
+
+	return service, nil
+}
+
+func (doc *document) request(path string, method string, item pathItem, op operation) (models.HttpRequestTemplate, error) {
+	request := models.HttpRequestTemplate{
+		Name:        op.OperationID,
+		Description: op.Summary,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+	}
+
+	if request.Name == "" {
+		request.Name = models.RouteName(method, path)
+	}
+
+	for _, p := range doc.parameters(item.Parameters, op.Parameters) {
+		placeholder := "{{ " + variable.NormalizeKey(p.Name) + placeholderType(doc.resolveSchema(p.Schema)) + " }}"
+
+		switch p.In {
+		case "path":
+			request.Path = strings.ReplaceAll(request.Path, "{"+p.Name+"}", placeholder)
+		case "query":
+			if !p.Required {
+				continue
+			}
+			if request.Query == nil {
+				request.Query = make(map[string]string)
+			}
+			request.Query[p.Name] = placeholder
+		case "header":
+			if !p.Required {
+				continue
+			}
+			if request.Headers == nil {
+				request.Headers = make(map[string]string)
+			}
+			request.Headers[p.Name] = placeholder
+		}
+	}
+
+	if op.RequestBody != nil {
+		err := doc.setBody(&request, doc.resolveRequestBody(*op.RequestBody))
+		if err != nil {
+			return request, err
+		}
+	}
+
+	return request, nil
+}
+
+// parameters returns the parameters of the operation along with the ones of its path,
+// parameters of the operation override the ones of the path.
+func (doc *document) parameters(pathParameters, operationParameters []parameter) []parameter {
+	var parameters []parameter
+	index := make(map[string]int)
+
+	for _, p := range append(pathParameters, operationParameters...) {
+		p = doc.resolveParameter(p)
+
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			parameters[i] = p
+			continue
+		}
+
+		index[key] = len(parameters)
+		parameters = append(parameters, p)
+	}
+
+	return parameters
+}
+
+// setBody sets the body of the request from the first supported content type,
+// json is preferred over the rest.
+func (doc *document) setBody(request *models.HttpRequestTemplate, body requestBody) error {
+	contentTypes := make([]string, 0, len(body.Content))
+	for contentType := range body.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.SliceStable(contentTypes, func(i, j int) bool {
+		return isJSON(contentTypes[i]) && !isJSON(contentTypes[j])
+	})
+
+	for _, contentType := range contentTypes {
+		media := body.Content[contentType]
+		value := doc.exampleValue(media)
+		if value == nil {
+			continue
+		}
+
+		switch {
+		case isJSON(contentType):
+			content, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			request.JsonBody = models.JSONBody{Text: string(content)}
+
+			if contentType != "application/json" {
+				setHeader(request, "Content-Type", contentType)
+			}
+		case contentType == "application/x-www-form-urlencoded":
+			fields, ok := value.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			request.FormBody = models.FormBody{}
+			for key, field := range fields {
+				request.FormBody[key] = models.StringList{fmt.Sprintf("%v", field)}
+			}
+		case isXML(contentType):
+			text, ok := value.(string)
+			if !ok {
+				continue
+			}
+
+			request.XmlBody = text
+
+			if contentType != "application/xml" {
+				setHeader(request, "Content-Type", contentType)
+			}
+		case strings.HasPrefix(contentType, "text/"):
+			text, ok := value.(string)
+			if !ok {
+				continue
+			}
+
+			request.Body = text
+			setHeader(request, "Content-Type", contentType)
+		default:
+			continue
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// exampleValue returns the example of the media type, or a sample made from its schema.
+func (doc *document) exampleValue(media mediaType) any {
+	if media.Example != nil {
+		return media.Example
+	}
+
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if ex := doc.resolveExample(media.Examples[name]); ex.Value != nil {
+			return ex.Value
+		}
+	}
+
+	return doc.sample(media.Schema, make(map[string]bool))
+}
+
+// sample makes a value matching the schema, refs already being sampled
+// are skipped so that recursive schemas terminate.
+func (doc *document) sample(s *schema, sampling map[string]bool) any {
+	if s == nil {
+		return nil
+	}
+
+	if s.Ref != "" {
+		if sampling[s.Ref] {
+			return nil
+		}
+		sampling[s.Ref] = true
+		defer delete(sampling, s.Ref)
+	}
+
+	s = doc.resolveSchema(s)
+	if s == nil {
+		return nil
+	}
+
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case len(s.Examples) > 0:
+		return s.Examples[0]
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		value := make(map[string]any)
+		for _, part := range s.AllOf {
+			if fields, ok := doc.sample(part, sampling).(map[string]any); ok {
+				for key, field := range fields {
+					value[key] = field
+				}
+			}
+		}
+		return value
+	case len(s.OneOf) > 0:
+		return doc.sample(s.OneOf[0], sampling)
+	case len(s.AnyOf) > 0:
+		return doc.sample(s.AnyOf[0], sampling)
+	}
+
+	switch {
+	case s.Type.is("object") || len(s.Properties) > 0:
+		value := make(map[string]any, len(s.Properties))
+		for name, property := range s.Properties {
+			if field := doc.sample(property, sampling); field != nil {
+				value[name] = field
+			}
+		}
+		return value
+	case s.Type.is("array"):
+		if item := doc.sample(s.Items, sampling); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case s.Type.is("integer"), s.Type.is("number"):
+		return 0
+	case s.Type.is("boolean"):
+		return false
+	case s.Type.is("string"):
+		switch s.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+
+	return nil
+}
+
+func (doc *document) resolveSchema(s *schema) *schema {
+	for seen := 0; s != nil && s.Ref != "" && seen < 32; seen++ {
+		s = doc.Components.Schemas[refName(s.Ref, "schemas")]
+	}
+
+	return s
+}
+
+func (doc *document) resolveParameter(p parameter) parameter {
+	if p.Ref == "" {
+		return p
+	}
+
+	return doc.Components.Parameters[refName(p.Ref, "parameters")]
+}
+
+func (doc *document) resolveRequestBody(body requestBody) requestBody {
+	if body.Ref == "" {
+		return body
+	}
+
+	return doc.Components.RequestBodies[refName(body.Ref, "requestBodies")]
+}
+
+func (doc *document) resolveExample(ex example) example {
+	if ex.Ref == "" {
+		return ex
+	}
+
+	return doc.Components.Examples[refName(ex.Ref, "examples")]
+}
+
+// refName returns the name of the component the local ref points to,
+// for example: #/components/schemas/User becomes User.
+func refName(ref string, kind string) string {
+	return strings.TrimPrefix(ref, "#/components/"+kind+"/")
+}
+
+// placeholderType returns the type of the placeholder for the schema, strings
+// use placeholders without a type.
+func placeholderType(s *schema) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Type.is("integer"):
+		return ":int"
+	case s.Type.is("number"):
+		return ":float"
+	case s.Type.is("boolean"):
+		return ":bool"
+	}
+
+	return ""
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+func isXML(contentType string) bool {
+	return contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml")
+}
+
+func setHeader(request *models.HttpRequestTemplate, key, value string) {
+	if request.Headers == nil {
+		request.Headers = make(map[string]string)
+	}
+
+	request.Headers[key] = value
+}
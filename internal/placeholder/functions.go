@@ -0,0 +1,236 @@
+package placeholder
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Function is a function placeholders can call. The value piped to the function,
+// if any, is its last argument.
+type Function func(args ...any) (any, error)
+
+// Functions are the functions placeholders can call. A variable that is defined takes
+// precedence over the function with the same name.
+var Functions = map[string]Function{
+	"uuid":       uuidFunction,
+	"now":        now,
+	"date":       date,
+	"unix":       unix,
+	"randomInt":  randomInt,
+	"base64":     base64Function,
+	"sha256":     sha256Function,
+	"hmacSha256": hmacSHA256,
+	"urlquery":   urlquery,
+	"concat":     concat,
+}
+
+// bareFunctions are the functions called when their name is written alone, like {{ uuid }}.
+// The name of a function needing arguments, like {{ date }}, is a variable.
+var bareFunctions = map[string]bool{
+	"uuid": true,
+	"now":  true,
+	"unix": true,
+}
+
+// uuidFunction returns a random (version 4) uuid.
+func uuidFunction(args ...any) (any, error) {
+	if err := checkArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// now returns the current time, written using RFC 3339.
+func now(args ...any) (any, error) {
+	if err := checkArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return time.Now(), nil
+}
+
+// date formats the time using the Go layout, for example 2006-01-02.
+func date(args ...any) (any, error) {
+	if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	t, err := toTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Format(String(args[0])), nil
+}
+
+// unix returns the time, the current time when it is not given, as the number of
+// seconds since January 1, 1970 UTC.
+func unix(args ...any) (any, error) {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+
+	t := time.Now()
+	if len(args) == 1 {
+		var err error
+		if t, err = toTime(args[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.Unix(), nil
+}
+
+// randomInt returns a random integer between min and max, both included.
+func randomInt(args ...any) (any, error) {
+	if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	low, err := toInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	high, err := toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if high < low {
+		return nil, fmt.Errorf("max %d is less than min %d", high, low)
+	}
+
+	n, err := rand.Int(rand.Reader, new(big.Int).SetInt64(high-low+1))
+	if err != nil {
+		return nil, err
+	}
+
+	return low + n.Int64(), nil
+}
+
+// base64Function encodes the value using standard base64.
+func base64Function(args ...any) (any, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(String(args[0]))), nil
+}
+
+// sha256Function returns the hex encoded SHA-256 hash of the value.
+func sha256Function(args ...any) (any, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(String(args[0])))
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// hmacSHA256 returns the hex encoded HMAC-SHA256 of the value, signed with the key.
+func hmacSHA256(args ...any) (any, error) {
+	if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(String(args[0])))
+	mac.Write([]byte(String(args[1])))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// urlquery escapes the value so that it can be placed in a query param.
+func urlquery(args ...any) (any, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	return url.QueryEscape(String(args[0])), nil
+}
+
+// concat returns the values joined together.
+func concat(args ...any) (any, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(String(arg))
+	}
+
+	return b.String(), nil
+}
+
+// checkArgs checks that the number of arguments is between min and max.
+func checkArgs(args []any, min, max int) error {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+
+	switch {
+	case max == 0:
+		return fmt.Errorf("expected no arguments, got %d", len(args))
+	case min == max:
+		return fmt.Errorf("expected %d arguments, got %d", min, len(args))
+	}
+
+	return fmt.Errorf("expected %d to %d arguments, got %d", min, max, len(args))
+}
+
+// toInt converts the value to an integer, variables are usually strings.
+func toInt(value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("'%s' is not an integer", String(value))
+}
+
+// toTime converts the value to a time, strings are either RFC 3339 times or, like
+// numbers, seconds since January 1, 1970 UTC.
+func toTime(value any) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+	}
+
+	if seconds, err := toInt(value); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("'%s' is not a time", String(value))
+}
@@ -0,0 +1,542 @@
+// Package placeholder parses and evaluates the placeholders of requests.
+//
+// A placeholder is written between {{ and }}, with a space after {{, and holds a
+// pipeline of commands separated by |. A command is either a value, a string, a
+// number, a bool or a variable, or a function called with its arguments. The value
+// of a command is passed to the function that follows it as its last argument:
+//
+//	{{ id }}
+//	{{ id:int }}
+//	{{ now | date "2006-01-02" }}
+//	{{ body | hmacSha256 secret }}
+package placeholder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// variableRegex matches variables along with their type: id or id:int
+	variableRegex = regexp.MustCompile(`^([a-zA-Z0-9]+)(?::(string|int|float|bool))?$`)
+)
+
+// Placeholder is a placeholder found in a string.
+type Placeholder struct {
+	// Start and End are the offsets of the placeholder in the string.
+	Start int
+	End   int
+
+	// Text is the placeholder as it is written, {{ and }} included.
+	Text string
+
+	commands []command
+}
+
+// Variable is a variable used by a placeholder. Type is the type the value typed
+// at the prompt must have, empty when it can be anything.
+type Variable struct {
+	Name string
+	Type string
+}
+
+// command is a value, when function is empty, or a call to the function.
+type command struct {
+	function string
+	args     []operand
+}
+
+// operand is a literal value or a name, the name of a variable or of a function that
+// needs no arguments.
+type operand struct {
+	literal  any
+	variable *Variable
+}
+
+// Lookup returns the value of the variable.
+type Lookup func(name string, inputType string) (any, error)
+
+// Defined reports whether the variable is defined. A nil Defined defines no variable.
+type Defined func(name string) bool
+
+// errNotPlaceholder is returned when the text isn't a placeholder and is left as it is.
+var errNotPlaceholder = errors.New("not a placeholder")
+
+// Parse returns the placeholders found in s. Text that isn't a valid placeholder, like
+// {{name}}, {{ id}}, {{ .Name }} or {{ that is never closed, is left as it is. Only
+// placeholders calling known functions with invalid arguments are errors.
+func Parse(s string) ([]Placeholder, error) {
+	var placeholders []Placeholder
+
+	for offset := 0; ; {
+		index := strings.Index(s[offset:], "{{")
+		if index == -1 {
+			return placeholders, nil
+		}
+		start := offset + index
+		offset = start + 2
+
+		if offset >= len(s) || !unicode.IsSpace(rune(s[offset])) || !strings.Contains(s[offset:], "}}") {
+			continue
+		}
+
+		p, err := parsePlaceholder(s, start)
+		if errors.Is(err, errNotPlaceholder) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		placeholders = append(placeholders, p)
+		offset = p.End
+	}
+}
+
+// Replace replaces every placeholder in s with the text returned by replace.
+func Replace(s string, replace func(p Placeholder) (string, error)) (string, error) {
+	placeholders, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	if len(placeholders) == 0 {
+		return s, nil
+	}
+
+	var result strings.Builder
+	last := 0
+
+	for _, p := range placeholders {
+		text, err := replace(p)
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteString(s[last:p.Start])
+		result.WriteString(text)
+		last = p.End
+	}
+
+	result.WriteString(s[last:])
+
+	return result.String(), nil
+}
+
+// Whole returns the placeholder when s is made of a single placeholder and nothing else.
+func Whole(s string) (Placeholder, bool) {
+	placeholders, err := Parse(s)
+	if err != nil || len(placeholders) != 1 || placeholders[0].Start != 0 || placeholders[0].End != len(s) {
+		return Placeholder{}, false
+	}
+
+	return placeholders[0], true
+}
+
+// Variable returns the variable when the placeholder is made of just a variable, like {{ id }}.
+// A name that is both a function and a variable that isn't defined is a function call.
+func (p Placeholder) Variable(defined Defined) (Variable, bool) {
+	if len(p.commands) != 1 || p.commands[0].function != "" {
+		return Variable{}, false
+	}
+
+	v := p.commands[0].args[0].variable
+	if v == nil || v.isCall(defined, Functions) {
+		return Variable{}, false
+	}
+
+	return *v, true
+}
+
+// Variables returns the variables used by the placeholder, in the order they are used.
+func (p Placeholder) Variables(defined Defined) []Variable {
+	var variables []Variable
+
+	for _, c := range p.commands {
+		for _, arg := range c.args {
+			if arg.variable != nil && !arg.variable.isCall(defined, Functions) {
+				variables = append(variables, *arg.variable)
+			}
+		}
+	}
+
+	return variables
+}
+
+// Expression returns the pipeline of the placeholder without {{ and }}, written
+// the same way whatever the spacing of the placeholder is, for example now | unix.
+func (p Placeholder) Expression() string {
+	commands := make([]string, 0, len(p.commands))
+
+	for _, c := range p.commands {
+		var parts []string
+		if c.function != "" {
+			parts = append(parts, c.function)
+		}
+
+		for _, arg := range c.args {
+			parts = append(parts, arg.String())
+		}
+
+		commands = append(commands, strings.Join(parts, " "))
+	}
+
+	return strings.Join(commands, " | ")
+}
+
+// Eval evaluates the placeholder, values of variables are read using lookup.
+func (p Placeholder) Eval(lookup Lookup, defined Defined, functions map[string]Function) (any, error) {
+	var value any
+
+	for i, c := range p.commands {
+		args := make([]any, 0, len(c.args)+1)
+		for _, arg := range c.args {
+			argValue, err := p.evalOperand(arg, lookup, defined, functions)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, argValue)
+		}
+
+		if c.function == "" {
+			value = args[0]
+			continue
+		}
+
+		// The value of the previous command is passed as the last argument
+		if i > 0 {
+			args = append(args, value)
+		}
+
+		var err error
+		value, err = p.call(c.function, args, functions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+func (p Placeholder) evalOperand(o operand, lookup Lookup, defined Defined, functions map[string]Function) (any, error) {
+	switch {
+	case o.variable != nil && o.variable.isCall(defined, functions):
+		return p.call(o.variable.Name, nil, functions)
+	case o.variable != nil:
+		return lookup(o.variable.Name, o.variable.Type)
+	}
+
+	return o.literal, nil
+}
+
+// isCall reports whether the name is a call to the function without arguments. Only
+// functions that need no arguments are called this way, defined variables take precedence
+// over them and typed names are always variables.
+func (v Variable) isCall(defined Defined, functions map[string]Function) bool {
+	if v.Type != "" || !bareFunctions[v.Name] || (defined != nil && defined(v.Name)) {
+		return false
+	}
+
+	_, ok := functions[v.Name]
+	return ok
+}
+
+func (o operand) String() string {
+	switch {
+	case o.variable != nil && o.variable.Type != "":
+		return o.variable.Name + ":" + o.variable.Type
+	case o.variable != nil:
+		return o.variable.Name
+	}
+
+	if s, ok := o.literal.(string); ok {
+		return strconv.Quote(s)
+	}
+
+	return fmt.Sprint(o.literal)
+}
+
+// call calls the function, errors hold the placeholder so that it can be found.
+func (p Placeholder) call(name string, args []any, functions map[string]Function) (any, error) {
+	function, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("'%s': function '%s' is not defined", p.Text, name)
+	}
+
+	value, err := function(args...)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %s: %w", p.Text, name, err)
+	}
+
+	return value, nil
+}
+
+// String returns the text written in place of a value. Strings are written as they are,
+// times using RFC 3339 and other values, like exported objects, as json.
+func String(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case nil:
+		return ""
+	}
+
+	var b strings.Builder
+
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parsePlaceholder parses the placeholder starting at start, at the {{. It returns
+// errNotPlaceholder unless the text is a pipeline of known functions.
+func parsePlaceholder(s string, start int) (Placeholder, error) {
+	p := Placeholder{Start: start}
+
+	tokens, end, err := lex(s, start+2)
+	if errors.Is(err, errNotPlaceholder) || (err != nil && !callsFunctions(tokens)) {
+		return p, errNotPlaceholder
+	}
+	if err != nil {
+		return p, fmt.Errorf("invalid placeholder '%s': %w", placeholderText(s, start), err)
+	}
+
+	p.End = end
+	p.Text = s[start:end]
+
+	p.commands, err = parseCommands(tokens)
+	if err != nil && !callsFunctions(tokens) {
+		return p, errNotPlaceholder
+	}
+	if err != nil {
+		return p, fmt.Errorf("invalid placeholder '%s': %w", p.Text, err)
+	}
+
+	return p, nil
+}
+
+// callsFunctions reports whether every command of the tokens starts with a known
+// function, in which case the text is meant to be a placeholder.
+func callsFunctions(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+
+	head := true
+	for _, t := range tokens {
+		switch {
+		case t.kind == tokenPipe && head:
+			return false
+		case t.kind == tokenPipe:
+			head = true
+		case head && (t.kind != tokenWord || !isFunction(t.value)):
+			return false
+		default:
+			head = false
+		}
+	}
+
+	return !head
+}
+
+// placeholderText returns the text of the placeholder starting at start, up to the
+// first }}, for error messages.
+func placeholderText(s string, start int) string {
+	end := strings.Index(s[start:], "}}")
+	if end == -1 {
+		return s[start:]
+	}
+
+	return s[start : start+end+2]
+}
+
+// token is a string literal, a pipe or a word: a name, a number or a bool.
+type token struct {
+	kind  byte
+	value string
+}
+
+const (
+	tokenWord   = 'w'
+	tokenString = 's'
+	tokenPipe   = '|'
+)
+
+// lex splits the placeholder, starting after {{, into tokens. It returns the offset
+// after the closing }}, which must follow a space. On errors, the tokens read so far
+// are returned.
+func lex(s string, offset int) ([]token, int, error) {
+	var tokens []token
+
+	for i := offset; i < len(s); {
+		c := s[i]
+
+		switch {
+		case strings.HasPrefix(s[i:], "}}") && !unicode.IsSpace(rune(s[i-1])):
+			return tokens, 0, errNotPlaceholder
+		case strings.HasPrefix(s[i:], "}}"):
+			return tokens, i + 2, nil
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '|':
+			tokens = append(tokens, token{kind: tokenPipe, value: "|"})
+			i++
+		case c == '"' || c == '`':
+			end := stringEnd(s, i)
+			if end == -1 {
+				return tokens, 0, fmt.Errorf("unterminated string")
+			}
+
+			value, err := strconv.Unquote(s[i:end])
+			if err != nil {
+				return tokens, 0, fmt.Errorf("invalid string %s", s[i:end])
+			}
+
+			tokens = append(tokens, token{kind: tokenString, value: value})
+			i = end
+		default:
+			end := i
+			for end < len(s) && !unicode.IsSpace(rune(s[end])) && !strings.ContainsRune("|\"`", rune(s[end])) && !strings.HasPrefix(s[end:], "}}") {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, value: s[i:end]})
+			i = end
+		}
+	}
+
+	return tokens, 0, fmt.Errorf("missing }}")
+}
+
+// stringEnd returns the offset after the string literal starting at start, -1 when
+// it is not terminated on the same line.
+func stringEnd(s string, start int) int {
+	quote := s[start]
+
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case s[i] == quote:
+			return i + 1
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == '\n' && quote == '"':
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// parseCommands parses the tokens of a placeholder into its pipeline of commands.
+func parseCommands(tokens []token) ([]command, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("placeholder is empty")
+	}
+
+	var commands []command
+	var current []token
+
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i].kind != tokenPipe {
+			current = append(current, tokens[i])
+			continue
+		}
+
+		if len(current) == 0 {
+			return nil, fmt.Errorf("missing command around |")
+		}
+
+		c, err := parseCommand(current, len(commands) > 0)
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, c)
+		current = nil
+	}
+
+	return commands, nil
+}
+
+// parseCommand parses a command, piped commands must be function calls. A name alone is
+// parsed as a variable, whether it is a function is known once variables are defined.
+// Like before functions existed, any word alone is a name, {{ 123 }} included.
+func parseCommand(tokens []token, piped bool) (command, error) {
+	first := tokens[0]
+
+	if first.kind == tokenWord && isFunction(first.value) && (piped || len(tokens) > 1) {
+		args := make([]operand, 0, len(tokens)-1)
+		for _, t := range tokens[1:] {
+			arg, err := parseOperand(t)
+			if err != nil {
+				return command{}, err
+			}
+			args = append(args, arg)
+		}
+
+		return command{function: first.value, args: args}, nil
+	}
+
+	if piped {
+		return command{}, fmt.Errorf("can't pipe to '%s', it is not a function", first.value)
+	}
+
+	if len(tokens) > 1 {
+		return command{}, fmt.Errorf("function '%s' is not defined", first.value)
+	}
+
+	if match := variableRegex.FindStringSubmatch(first.value); first.kind == tokenWord && match != nil {
+		return command{args: []operand{{variable: &Variable{Name: match[1], Type: match[2]}}}}, nil
+	}
+
+	value, err := parseOperand(first)
+	if err != nil {
+		return command{}, err
+	}
+
+	return command{args: []operand{value}}, nil
+}
+
+// parseOperand parses a literal or a name.
+func parseOperand(t token) (operand, error) {
+	if t.kind == tokenString {
+		return operand{literal: t.value}, nil
+	}
+
+	if t.value == "true" || t.value == "false" {
+		return operand{literal: t.value == "true"}, nil
+	}
+
+	if n, err := strconv.Atoi(t.value); err == nil {
+		return operand{literal: n}, nil
+	}
+
+	if f, err := strconv.ParseFloat(t.value, 64); err == nil && strings.ContainsAny(t.value[:1], "-.0123456789") {
+		return operand{literal: f}, nil
+	}
+
+	match := variableRegex.FindStringSubmatch(t.value)
+	if match == nil {
+		return operand{}, fmt.Errorf("unexpected '%s'", t.value)
+	}
+
+	return operand{variable: &Variable{Name: match[1], Type: match[2]}}, nil
+}
+
+func isFunction(name string) bool {
+	_, ok := Functions[name]
+	return ok
+}
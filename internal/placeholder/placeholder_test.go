@@ -0,0 +1,234 @@
+package placeholder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expressions []string
+		wantErr     string
+	}{
+		{name: "no placeholder", input: "/users/1"},
+		{name: "variable", input: "/users/{{ id }}", expressions: []string{"id"}},
+		{name: "typed variable", input: "{{ id:int }}", expressions: []string{"id:int"}},
+		{name: "number is a name", input: "{{ 123 }}", expressions: []string{"123"}},
+		{name: "several", input: "{{ a }}-{{ b }}", expressions: []string{"a", "b"}},
+		{name: "extra spacing", input: "{{   now|unix   }}", expressions: []string{"now | unix"}},
+		{name: "function with arguments", input: `{{ randomInt 1 10 }}`, expressions: []string{"randomInt 1 10"}},
+		{name: "pipeline", input: `{{ now | date "2006-01-02" }}`, expressions: []string{`now | date "2006-01-02"`}},
+		{name: "backquoted string", input: "{{ date `2006` now }}", expressions: []string{`date "2006" now`}},
+		{name: "string with }}", input: `{{ concat "}}" id }}`, expressions: []string{`concat "}}" id`}},
+		{name: "no space after {{", input: "{{id}}"},
+		{name: "no space before }}", input: "{{ id}}"},
+		{name: "go template", input: "{{ .Name }}"},
+		{name: "unknown function", input: "{{ id | upper }}"},
+		{name: "never closed", input: "{{ id"},
+		{name: "invalid text before a placeholder", input: "{{ .Name }} {{ id }}", expressions: []string{"id"}},
+		{name: "dangling pipe", input: "{{ randomInt 1 | }}"},
+		{name: "invalid argument", input: "{{ base64 $name }}", wantErr: "unexpected '$name'"},
+		{name: "unterminated string", input: `{{ date "2006 }}`, wantErr: "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placeholders, err := Parse(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+
+			var expressions []string
+			for _, p := range placeholders {
+				if tt.input[p.Start:p.End] != p.Text {
+					t.Errorf("Parse(%q) text = %q, offsets point to %q", tt.input, p.Text, tt.input[p.Start:p.End])
+				}
+				expressions = append(expressions, p.Expression())
+			}
+
+			if !reflect.DeepEqual(expressions, tt.expressions) {
+				t.Errorf("Parse(%q) = %q, want %q", tt.input, expressions, tt.expressions)
+			}
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		defined   []string
+		variables []Variable
+		variable  bool
+	}{
+		{name: "variable", input: "{{ id }}", variables: []Variable{{Name: "id"}}, variable: true},
+		{name: "typed variable", input: "{{ id:int }}", variables: []Variable{{Name: "id", Type: "int"}}, variable: true},
+		{name: "number", input: "{{ 123 }}", variables: []Variable{{Name: "123"}}, variable: true},
+		{name: "function without arguments", input: "{{ uuid }}"},
+		{name: "defined variable named like a function", input: "{{ uuid }}", defined: []string{"uuid"}, variables: []Variable{{Name: "uuid"}}, variable: true},
+		{name: "function needing arguments", input: "{{ date }}", variables: []Variable{{Name: "date"}}, variable: true},
+		{name: "typed name of a function", input: "{{ now:string }}", variables: []Variable{{Name: "now", Type: "string"}}, variable: true},
+		{name: "arguments", input: "{{ hmacSha256 secret body }}", variables: []Variable{{Name: "secret"}, {Name: "body"}}},
+		{name: "piped", input: `{{ createdAt | date "2006" }}`, variables: []Variable{{Name: "createdAt"}}},
+		{name: "literal arguments", input: `{{ randomInt 1 10 }}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := Whole(tt.input)
+			if !ok {
+				t.Fatalf("Whole(%q) is not a placeholder", tt.input)
+			}
+
+			defined := func(name string) bool {
+				for _, d := range tt.defined {
+					if d == name {
+						return true
+					}
+				}
+				return false
+			}
+
+			if variables := p.Variables(defined); !reflect.DeepEqual(variables, tt.variables) {
+				t.Errorf("Variables() = %v, want %v", variables, tt.variables)
+			}
+
+			if _, ok := p.Variable(defined); ok != tt.variable {
+				t.Errorf("Variable() ok = %v, want %v", ok, tt.variable)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	values := map[string]any{
+		"id":        "7",
+		"secret":    "key",
+		"body":      "hello",
+		"createdAt": "2024-05-01T10:00:00Z",
+		"date":      "set",
+		"123":       "number",
+	}
+
+	lookup := func(name string, _ string) (any, error) {
+		value, ok := values[name]
+		if !ok {
+			return nil, errors.New("prompted for " + name)
+		}
+		return value, nil
+	}
+
+	defined := func(name string) bool {
+		_, ok := values[name]
+		return ok
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr string
+	}{
+		{name: "variable", input: "{{ id }}", want: "7"},
+		{name: "number is a variable", input: "{{ 123 }}", want: "number"},
+		{name: "defined variable over function", input: "{{ date }}", want: "set"},
+		{name: "undefined name of a function needing arguments", input: "{{ base64 }}", wantErr: "prompted for base64"},
+		{name: "undefined variable", input: "{{ missing }}", wantErr: "prompted for missing"},
+		{name: "string argument", input: `{{ base64 "user:pass" }}`, want: "dXNlcjpwYXNz"},
+		{name: "piped value", input: "{{ body | sha256 }}", want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "hmac", input: "{{ hmacSha256 secret body }}", want: "9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b"},
+		{name: "date of a variable", input: `{{ createdAt | date "2006-01-02" }}`, want: "2024-05-01"},
+		{name: "unix of a number", input: "{{ unix 1714557600 }}", want: int64(1714557600)},
+		{name: "urlquery", input: `{{ urlquery "a b&c" }}`, want: "a+b%26c"},
+		{name: "concat", input: `{{ concat id ":" body | base64 }}`, want: "NzpoZWxsbw=="},
+		{name: "randomInt", input: "{{ randomInt 3 3 }}", want: int64(3)},
+		{name: "wrong number of arguments", input: "{{ randomInt 1 }}", wantErr: "expected 2 arguments, got 1"},
+		{name: "max less than min", input: "{{ randomInt 5 1 }}", wantErr: "max 1 is less than min 5"},
+		{name: "not a time", input: `{{ date "2006" body }}`, wantErr: "'hello' is not a time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := Whole(tt.input)
+			if !ok {
+				t.Fatalf("Whole(%q) is not a placeholder", tt.input)
+			}
+
+			value, err := p.Eval(lookup, defined, Functions)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Eval(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Eval(%q) error = %v", tt.input, err)
+			}
+
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("Eval(%q) = %#v, want %#v", tt.input, value, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalFunctionsWithoutArguments(t *testing.T) {
+	lookup := func(name string, _ string) (any, error) {
+		return nil, errors.New("prompted for " + name)
+	}
+
+	for _, input := range []string{"{{ uuid }}", "{{ now }}", "{{ unix }}", "{{ now | unix }}"} {
+		p, ok := Whole(input)
+		if !ok {
+			t.Fatalf("Whole(%q) is not a placeholder", input)
+		}
+
+		if _, err := p.Eval(lookup, nil, Functions); err != nil {
+			t.Errorf("Eval(%q) error = %v", input, err)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	got, err := Replace("/users/{{ id }}?at={{id}}&n={{ 1 }}", func(p Placeholder) (string, error) {
+		return "<" + p.Expression() + ">", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/users/<id>?at={{id}}&n=<1>"; got != want {
+		t.Errorf("Replace() = %q, want %q", got, want)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{value: "text", want: "text"},
+		{value: nil, want: ""},
+		{value: 42, want: "42"},
+		{value: true, want: "true"},
+		{value: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), want: "2024-05-01T10:00:00Z"},
+		{value: map[string]any{"a": "<b>"}, want: `{"a":"<b>"}`},
+	}
+
+	for _, tt := range tests {
+		if got := String(tt.value); got != tt.want {
+			t.Errorf("String(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
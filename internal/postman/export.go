@@ -0,0 +1,416 @@
+package postman
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/jsonpath"
+	"github.com/gurleensethi/yurl/internal/placeholder"
+	"github.com/gurleensethi/yurl/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+const schemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+var (
+	identifierRegex = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+)
+
+// Export converts the template to a Postman collection (v2.1) with the given name.
+//
+// The config becomes collection variables, baseUrl for the default host and <service>BaseUrl
+// for every service, along with the variables of the template. Requests in a namespace are
+// placed in a folder named after it. Requests with pre-requests are placed in a folder of
+// their own, holding the pre-requests and the request in the order they are executed, so
+// that the folder can be run as a whole. Exports become test scripts setting collection
+// variables.
+func Export(template models.HttpTemplate, name string) ([]byte, error) {
+	c := collection{
+		Info: info{Name: name, Schema: schemaURL},
+		Item: []item{},
+	}
+
+	c.Variable = collectionVariables(template)
+
+	names := make([]string, 0, len(template.Requests))
+	for requestName, request := range template.Requests {
+		// Abstract requests only exist to be extended
+		if !request.Abstract {
+			names = append(names, requestName)
+		}
+	}
+	sort.Strings(names)
+
+	for _, requestName := range names {
+		request := template.Requests[requestName]
+
+		namespace := ""
+		shortName := requestName
+		if i := strings.LastIndex(requestName, "."); i != -1 {
+			namespace, shortName = requestName[:i], requestName[i+1:]
+		}
+
+		folder := &c.Item
+		if namespace != "" {
+			folder = folderItems(folder, strings.Split(namespace, "."))
+		}
+
+		requestItem, err := convertTemplate(template, request, shortName)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", requestName, err)
+		}
+
+		if len(request.PreRequests) == 0 {
+			*folder = append(*folder, requestItem)
+			continue
+		}
+
+		chainItems := []item{}
+		for _, preRequest := range executionChain(template, requestName) {
+			preRequestItem, err := convertTemplate(template, template.Requests[preRequest], preRequest)
+			if err != nil {
+				return nil, fmt.Errorf("'%s': %w", preRequest, err)
+			}
+			chainItems = append(chainItems, preRequestItem)
+		}
+
+		*folder = append(*folder, item{Name: shortName, Item: append(chainItems, requestItem)})
+	}
+
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// collectionVariables converts the config, and the variables of the template, to collection variables.
+func collectionVariables(template models.HttpTemplate) []keyValue {
+	var variables []keyValue
+
+	if template.Config.Host != "" {
+		service, _ := template.Config.Service("")
+		variables = append(variables, keyValue{Key: "baseUrl", Value: baseURL(service)})
+	}
+
+	serviceNames := make([]string, 0, len(template.Config.Services))
+	for serviceName := range template.Config.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		variables = append(variables, keyValue{
+			Key:   serviceName + "BaseUrl",
+			Value: baseURL(template.Config.Services[serviceName]),
+		})
+	}
+
+	keys := make([]string, 0, len(template.Variables))
+	for key := range template.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		variables = append(variables, keyValue{Key: key, Value: toPostmanVariables(template.Variables[key])})
+	}
+
+	return variables
+}
+
+func baseURL(service models.Service) string {
+	return toPostmanVariables(service.Scheme + "://" + service.Address() + strings.TrimSuffix(service.BasePath, "/"))
+}
+
+// folderItems returns the items of the folder at the path, creating the folders that don't exist.
+func folderItems(items *[]item, path []string) *[]item {
+	for _, name := range path {
+		index := -1
+		for i, it := range *items {
+			if it.Request == nil && it.Name == name {
+				index = i
+				break
+			}
+		}
+
+		if index == -1 {
+			*items = append(*items, item{Name: name, Item: []item{}})
+			index = len(*items) - 1
+		}
+
+		items = &(*items)[index].Item
+	}
+
+	return items
+}
+
+// executionChain returns the names of the pre-requests of the request, including
+// the ones of its pre-requests, in the order they are executed.
+func executionChain(template models.HttpTemplate, name string) []string {
+	var chain []string
+	visited := map[string]bool{name: true}
+
+	var visit func(name string)
+	visit = func(name string) {
+		for _, preRequest := range template.Requests[name].PreRequests {
+			if visited[preRequest.Name] {
+				continue
+			}
+			visited[preRequest.Name] = true
+
+			visit(preRequest.Name)
+			chain = append(chain, preRequest.Name)
+		}
+	}
+	visit(name)
+
+	return chain
+}
+
+// convertTemplate converts the request to a Postman request item, headers and
+// query params from the config are added to it.
+func convertTemplate(template models.HttpTemplate, requestTemplate models.HttpRequestTemplate, name string) (item, error) {
+	// Postman collections only hold http requests
+	if requestTemplate.WebSocket != nil {
+		return item{}, errors.New("websocket requests are not supported")
+	}
+
+	service, _ := template.Config.Service(requestTemplate.Service)
+	if requestTemplate.Socket != "" || requestTemplate.URL == "" && service.Socket != "" {
+		return item{}, errors.New("unix socket requests are not supported")
+	}
+
+	r := &request{
+		Method: requestTemplate.Method,
+		Header: []keyValue{},
+	}
+
+	if requestTemplate.URL != "" {
+		r.URL.Raw = toPostmanVariables(requestTemplate.URL)
+	} else {
+		baseURLVariable := "{{baseUrl}}"
+		if requestTemplate.Service != "" {
+			baseURLVariable = "{{" + requestTemplate.Service + "BaseUrl}}"
+		}
+		r.URL.Raw = baseURLVariable + "/" + strings.TrimPrefix(toPostmanVariables(requestTemplate.Path), "/")
+	}
+
+	query := mergeMaps(template.Config.Query, requestTemplate.Query)
+	if len(query) > 0 {
+		params := make([]string, 0, len(query))
+		for _, key := range sortedKeys(query) {
+			value := toPostmanVariables(query[key])
+			r.URL.Query = append(r.URL.Query, keyValue{Key: key, Value: value})
+			params = append(params, key+"="+value)
+		}
+		r.URL.Raw += "?" + strings.Join(params, "&")
+	}
+
+	headers := mergeMaps(template.Config.Headers, requestTemplate.Headers)
+
+	switch {
+	case requestTemplate.GraphQL != nil:
+		variables, err := requestTemplate.GraphQL.Variables.Render(postmanJSONScalar)
+		if err != nil {
+			return item{}, err
+		}
+
+		// Postman has no field for the operation name, it picks the operation from the query
+		r.Body = &body{Mode: "graphql", GraphQL: &graphql{
+			Query:     toPostmanVariables(requestTemplate.GraphQL.Query),
+			Variables: toPostmanVariables(variables),
+		}}
+
+		if headerValue(headers, "Content-Type") == "" {
+			headers["Content-Type"] = "application/json"
+		}
+	case !requestTemplate.JsonBody.IsZero():
+		raw, err := requestTemplate.JsonBody.Render(postmanJSONScalar)
+		if err != nil {
+			return item{}, err
+		}
+
+		r.Body = &body{Mode: "raw", Raw: toPostmanVariables(raw), Options: &bodyOptions{}}
+		r.Body.Options.Raw.Language = "json"
+
+		if headerValue(headers, "Content-Type") == "" {
+			headers["Content-Type"] = "application/json"
+		}
+	case requestTemplate.XmlBody != "":
+		r.Body = &body{Mode: "raw", Raw: toPostmanVariables(requestTemplate.XmlBody), Options: &bodyOptions{}}
+		r.Body.Options.Raw.Language = "xml"
+
+		if headerValue(headers, "Content-Type") == "" {
+			headers["Content-Type"] = "application/xml"
+		}
+	case len(requestTemplate.FormBody) > 0:
+		r.Body = &body{Mode: "urlencoded"}
+		for _, key := range sortedKeys(requestTemplate.FormBody) {
+			for _, value := range requestTemplate.FormBody[key] {
+				r.Body.URLEncoded = append(r.Body.URLEncoded, keyValue{Key: key, Value: toPostmanVariables(value)})
+			}
+		}
+
+		if headerValue(headers, "Content-Type") == "" {
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	case len(requestTemplate.Multipart) > 0:
+		r.Body = &body{Mode: "formdata"}
+		for _, field := range requestTemplate.Multipart {
+			if field.File == "" {
+				r.Body.FormData = append(r.Body.FormData, formData{
+					Key:   toPostmanVariables(field.Name),
+					Value: toPostmanVariables(field.Value),
+					Type:  "text",
+				})
+				continue
+			}
+
+			r.Body.FormData = append(r.Body.FormData, formData{
+				Key:         toPostmanVariables(field.Name),
+				Type:        "file",
+				Src:         toPostmanVariables(field.File),
+				ContentType: field.ContentType,
+			})
+		}
+
+		// Postman sets the content type, along with the boundary, on its own
+		deleteHeader(headers, "Content-Type")
+	case requestTemplate.BodyFile != nil:
+		// Postman sends files as they are, templates are not supported
+		r.Body = &body{Mode: "file", File: &bodyFile{Src: toPostmanVariables(requestTemplate.BodyFile.Path)}}
+	case requestTemplate.Body != "":
+		r.Body = &body{Mode: "raw", Raw: toPostmanVariables(requestTemplate.Body)}
+
+		if headerValue(headers, "Content-Type") == "" {
+			headers["Content-Type"] = "text/plain"
+		}
+	}
+
+	for _, key := range sortedKeys(headers) {
+		r.Header = append(r.Header, keyValue{Key: key, Value: toPostmanVariables(headers[key])})
+	}
+
+	it := item{Name: name, Request: r}
+
+	if len(requestTemplate.Exports) > 0 {
+		exec := []string{"const body = pm.response.json();"}
+
+		// Exports of graphql requests are read from the data of the response
+		if requestTemplate.GraphQL != nil {
+			exec[0] = "const body = pm.response.json().data;"
+		}
+
+		for _, key := range sortedKeys(requestTemplate.Exports) {
+			if requestTemplate.Exports[key].XPath != "" {
+				return it, fmt.Errorf("export '%s': xpath exports are not supported", key)
+			}
+
+			accessor, err := jsonPathAccessor(requestTemplate.Exports[key].JSON)
+			if err != nil {
+				return it, fmt.Errorf("export '%s': %w", key, err)
+			}
+
+			exec = append(exec, fmt.Sprintf("pm.collectionVariables.set(%s, body%s);", strconv.Quote(key), accessor))
+		}
+
+		it.Event = []event{{Listen: "test", Script: script{Type: "text/javascript", Exec: exec}}}
+	}
+
+	return it, nil
+}
+
+// jsonPathAccessor converts a JSONPath, made of names and indexes, to a JavaScript
+// property accessor, for example: $.data.items[0] becomes .data.items[0].
+func jsonPathAccessor(path string) (string, error) {
+	segments, err := jsonpath.Segments(path)
+	if err != nil {
+		return "", err
+	}
+
+	var accessor strings.Builder
+
+	for _, segment := range segments {
+		switch segment := segment.(type) {
+		case int:
+			fmt.Fprintf(&accessor, "[%d]", segment)
+		case string:
+			if identifierRegex.MatchString(segment) {
+				accessor.WriteString("." + segment)
+			} else {
+				accessor.WriteString("[" + strconv.Quote(segment) + "]")
+			}
+		}
+	}
+
+	return accessor.String(), nil
+}
+
+// postmanJSONScalar converts the scalar of a json body written as yaml to json. A typed
+// variable used as the whole value is written without quotes, like {{id}} for {{ id:int }}.
+func postmanJSONScalar(node *yaml.Node) (string, error) {
+	if p, ok := placeholder.Whole(node.Value); node.ShortTag() == "!!str" && ok {
+		if v, ok := p.Variable(nil); ok && v.Type != "" && v.Type != "string" {
+			return "{{" + v.Name + "}}", nil
+		}
+	}
+
+	return models.JSONScalar(node)
+}
+
+// dynamicVariables are the Postman dynamic variables placeholders calling functions are
+// converted to, placeholders without an equivalent are exported as they are.
+var dynamicVariables = map[string]string{
+	"uuid":             "{{$guid}}",
+	"now":              "{{$isoTimestamp}}",
+	"now | unix":       "{{$timestamp}}",
+	"unix":             "{{$timestamp}}",
+	"randomInt 0 1000": "{{$randomInt}}",
+}
+
+// toPostmanVariables converts yurl variables to Postman variables: {{ id:int }} becomes {{id}}.
+func toPostmanVariables(s string) string {
+	converted, err := placeholder.Replace(s, func(p placeholder.Placeholder) (string, error) {
+		if v, ok := p.Variable(nil); ok {
+			return "{{" + v.Name + "}}", nil
+		}
+
+		if dynamicVariable, ok := dynamicVariables[p.Expression()]; ok {
+			return dynamicVariable, nil
+		}
+
+		return p.Text, nil
+	})
+	if err != nil {
+		return s
+	}
+
+	return converted
+}
+
+// mergeMaps returns a new map with values of override taking precedence over base.
+func mergeMaps(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
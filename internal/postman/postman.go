@@ -0,0 +1,562 @@
+// Package postman converts Postman collections (v2.1) and environments to requests.
+package postman
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/yurl/internal/placeholder"
+	"github.com/gurleensethi/yurl/internal/variable"
+	"github.com/gurleensethi/yurl/pkg/models"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("only Postman collections v2.1 are supported")
+
+	placeholderRegex = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+	// pathVariableRegex matches path variables: /users/:id
+	pathVariableRegex = regexp.MustCompile(`/:([A-Za-z_][\w-]*)`)
+)
+
+type collection struct {
+	Info     info       `json:"info"`
+	Item     []item     `json:"item"`
+	Variable []keyValue `json:"variable,omitempty"`
+	Auth     *auth      `json:"auth,omitempty"`
+}
+
+type info struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// item is either a folder, with items of its own, or a request.
+type item struct {
+	Name    string   `json:"name"`
+	Item    []item   `json:"item,omitempty"`
+	Request *request `json:"request,omitempty"`
+	Auth    *auth    `json:"auth,omitempty"`
+	Event   []event  `json:"event,omitempty"`
+}
+
+// event is a script run before or after the request, only test
+// scripts (run after the response is received) are exported.
+type event struct {
+	Listen string `json:"listen"`
+	Script script `json:"script"`
+}
+
+type script struct {
+	Type string   `json:"type"`
+	Exec []string `json:"exec"`
+}
+
+type request struct {
+	Method string     `json:"method"`
+	Header []keyValue `json:"header"`
+	URL    requestURL `json:"url"`
+	Body   *body      `json:"body,omitempty"`
+	Auth   *auth      `json:"auth,omitempty"`
+}
+
+// requestURL is the url of a request, written either as a string or an object.
+type requestURL struct {
+	Raw   string     `json:"raw"`
+	Query []keyValue `json:"query,omitempty"`
+}
+
+func (u *requestURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		u.Raw = raw
+		return nil
+	}
+
+	type plain requestURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+type body struct {
+	Mode       string       `json:"mode"`
+	Raw        string       `json:"raw,omitempty"`
+	URLEncoded []keyValue   `json:"urlencoded,omitempty"`
+	FormData   []formData   `json:"formdata,omitempty"`
+	File       *bodyFile    `json:"file,omitempty"`
+	GraphQL    *graphql     `json:"graphql,omitempty"`
+	Options    *bodyOptions `json:"options,omitempty"`
+}
+
+type bodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type bodyFile struct {
+	Src string `json:"src"`
+}
+
+// formData is a field of a multipart body, Src is the path of a file,
+// or a list of paths, when Type is file.
+type formData struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Src         any    `json:"src,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// files returns the paths of the files of the field.
+func (f formData) files() []string {
+	switch src := f.Src.(type) {
+	case string:
+		return []string{src}
+	case []any:
+		files := make([]string, 0, len(src))
+		for _, file := range src {
+			if path, ok := file.(string); ok {
+				files = append(files, path)
+			}
+		}
+		return files
+	}
+
+	return nil
+}
+
+type graphql struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables"`
+}
+
+type auth struct {
+	Type   string     `json:"type"`
+	Bearer []keyValue `json:"bearer,omitempty"`
+	Basic  []keyValue `json:"basic,omitempty"`
+	APIKey []keyValue `json:"apikey,omitempty"`
+}
+
+type keyValue struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+// value returns the value as a string with placeholders converted to yurl variables.
+func (kv keyValue) value() string {
+	if kv.Value == nil {
+		return ""
+	}
+
+	if s, ok := kv.Value.(string); ok {
+		return convertPlaceholders(s)
+	}
+
+	return fmt.Sprintf("%v", kv.Value)
+}
+
+func (kv keyValue) enabled() bool {
+	return !kv.Disabled && (kv.Enabled == nil || *kv.Enabled)
+}
+
+func find(values []keyValue, key string) string {
+	for _, kv := range values {
+		if kv.Key == key {
+			return kv.value()
+		}
+	}
+
+	return ""
+}
+
+type environment struct {
+	Name   string     `json:"name"`
+	Values []keyValue `json:"values"`
+}
+
+// Parse converts every request of the Postman collection to a request. Requests inside
+// folders are namespaced by the folder, collection variables become template variables
+// and auth, inherited from folders and the collection, becomes headers.
+func Parse(r io.Reader) (*models.HttpTemplate, error) {
+	var c collection
+
+	err := json.NewDecoder(r).Decode(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Info.Schema != "" && !strings.Contains(c.Info.Schema, "v2.1") {
+		return nil, ErrUnsupportedVersion
+	}
+
+	template := &models.HttpTemplate{
+		Variables: make(map[string]string),
+		Requests:  make(map[string]models.HttpRequestTemplate),
+	}
+
+	for _, kv := range c.Variable {
+		template.Variables[variable.NormalizeKey(kv.Key)] = kv.value()
+	}
+
+	err = addItems(template, "", c.Item, c.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// ParseEnvironment converts the Postman environment to an environment, returning it along with its name.
+func ParseEnvironment(r io.Reader) (string, models.Environment, error) {
+	var e environment
+
+	err := json.NewDecoder(r).Decode(&e)
+	if err != nil {
+		return "", models.Environment{}, err
+	}
+
+	env := models.Environment{
+		Variables: make(map[string]string),
+	}
+
+	for _, kv := range e.Values {
+		if !kv.enabled() {
+			continue
+		}
+
+		env.Variables[variable.NormalizeKey(kv.Key)] = kv.value()
+	}
+
+	return lowerName(e.Name), env, nil
+}
+
+func addItems(template *models.HttpTemplate, namespace string, items []item, inheritedAuth *auth) error {
+	for _, it := range items {
+		itemAuth := inheritedAuth
+		if it.Auth != nil {
+			itemAuth = it.Auth
+		}
+
+		// Folder
+		if it.Request == nil {
+			err := addItems(template, models.QualifiedName(namespace, lowerName(it.Name)), it.Item, itemAuth)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if it.Request.Auth != nil {
+			itemAuth = it.Request.Auth
+		}
+
+		name := variable.NormalizeKey(it.Name)
+		if name == "" {
+			name = "request"
+		}
+		name = models.QualifiedName(namespace, strings.ToUpper(name[:1])+name[1:])
+
+		// Requests with the same name in a folder are numbered.
+		for i, base := 2, name; ; i++ {
+			if _, ok := template.Requests[name]; !ok {
+				break
+			}
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+
+		request, err := convertRequest(*it.Request, itemAuth)
+		if err != nil {
+			return fmt.Errorf("'%s': %w", it.Name, err)
+		}
+
+		request.Name = name
+		request.Description = it.Name
+
+		template.Requests[name] = request
+	}
+
+	return nil
+}
+
+func convertRequest(r request, requestAuth *auth) (models.HttpRequestTemplate, error) {
+	request := models.HttpRequestTemplate{
+		Method:  strings.ToUpper(r.Method),
+		Headers: make(map[string]string),
+		Query:   make(map[string]string),
+	}
+
+	if request.Method == "" {
+		request.Method = "GET"
+	}
+
+	rawURL, rawQuery, _ := strings.Cut(r.URL.Raw, "?")
+	rawURL = pathVariableRegex.ReplaceAllString(rawURL, "/{{$1}}")
+	rawURL = convertPlaceholders(rawURL)
+
+	if !strings.Contains(rawURL, "://") && !strings.HasPrefix(rawURL, "{{") {
+		rawURL = "http://" + rawURL
+	}
+	request.URL = rawURL
+
+	if r.URL.Query != nil {
+		for _, kv := range r.URL.Query {
+			if kv.enabled() {
+				request.Query[kv.Key] = kv.value()
+			}
+		}
+	} else if rawQuery != "" {
+		for _, param := range strings.Split(rawQuery, "&") {
+			key, value, _ := strings.Cut(param, "=")
+			request.Query[key] = convertPlaceholders(value)
+		}
+	}
+
+	for _, kv := range r.Header {
+		if kv.enabled() {
+			request.Headers[kv.Key] = kv.value()
+		}
+	}
+
+	if err := setAuth(&request, requestAuth); err != nil {
+		return request, err
+	}
+
+	if r.Body != nil {
+		err := setBody(&request, *r.Body)
+		if err != nil {
+			return request, err
+		}
+	}
+
+	if len(request.Headers) == 0 {
+		request.Headers = nil
+	}
+
+	if len(request.Query) == 0 {
+		request.Query = nil
+	}
+
+	return request, nil
+}
+
+// setAuth adds the auth to the headers, or the query, of the request.
+func setAuth(request *models.HttpRequestTemplate, a *auth) error {
+	if a == nil {
+		return nil
+	}
+
+	switch a.Type {
+	case "bearer":
+		request.Headers["Authorization"] = "Bearer " + find(a.Bearer, "token")
+	case "basic":
+		credentials, err := basicCredentials(find(a.Basic, "username") + ":" + find(a.Basic, "password"))
+		if err != nil {
+			return err
+		}
+
+		request.Headers["Authorization"] = "Basic " + credentials
+	case "apikey":
+		key, value := find(a.APIKey, "key"), find(a.APIKey, "value")
+
+		if find(a.APIKey, "in") == "query" {
+			request.Query[key] = value
+		} else {
+			request.Headers[key] = value
+		}
+	}
+
+	return nil
+}
+
+// basicCredentials returns the base64 encoded credentials, username:password. Credentials
+// made of variables are encoded by a placeholder, when the request is sent.
+func basicCredentials(credentials string) (string, error) {
+	placeholders, err := placeholder.Parse(credentials)
+	if err != nil {
+		return "", err
+	}
+
+	if len(placeholders) == 0 {
+		return base64.StdEncoding.EncodeToString([]byte(credentials)), nil
+	}
+
+	var args []string
+	last := 0
+
+	for _, p := range placeholders {
+		if p.Start > last {
+			args = append(args, strconv.Quote(credentials[last:p.Start]))
+		}
+
+		// Pipelines can't be arguments of concat
+		expression := p.Expression()
+		if strings.ContainsAny(expression, " |") {
+			return "", fmt.Errorf("basic auth made of '%s' is not supported", p.Text)
+		}
+
+		args = append(args, expression)
+		last = p.End
+	}
+
+	if last < len(credentials) {
+		args = append(args, strconv.Quote(credentials[last:]))
+	}
+
+	return "{{ concat " + strings.Join(args, " ") + " | base64 }}", nil
+}
+
+func setBody(request *models.HttpRequestTemplate, b body) error {
+	switch b.Mode {
+	case "", "none":
+	case "raw":
+		raw := convertPlaceholders(b.Raw)
+		if raw == "" {
+			return nil
+		}
+
+		contentType := strings.ToLower(headerValue(request.Headers, "Content-Type"))
+
+		if b.Options != nil && b.Options.Raw.Language == "json" || strings.Contains(contentType, "json") {
+			request.JsonBody = models.JSONBody{Text: raw}
+
+			// jsonBody sets the content type on its own
+			if contentType == "application/json" {
+				deleteHeader(request.Headers, "Content-Type")
+			}
+		} else if b.Options != nil && b.Options.Raw.Language == "xml" || strings.Contains(contentType, "xml") {
+			request.XmlBody = raw
+
+			// xmlBody sets the content type on its own
+			if contentType == "application/xml" {
+				deleteHeader(request.Headers, "Content-Type")
+			}
+		} else {
+			request.Body = raw
+		}
+	case "urlencoded":
+		form := models.FormBody{}
+		for _, kv := range b.URLEncoded {
+			if kv.enabled() {
+				key := convertPlaceholders(kv.Key)
+				form[key] = append(form[key], convertPlaceholders(kv.value()))
+			}
+		}
+
+		if len(form) > 0 {
+			request.FormBody = form
+
+			// formBody sets the content type on its own
+			deleteHeader(request.Headers, "Content-Type")
+		}
+	case "formdata":
+		for _, field := range b.FormData {
+			if field.Disabled {
+				continue
+			}
+
+			key := convertPlaceholders(field.Key)
+
+			if field.Type != "file" {
+				request.Multipart = append(request.Multipart, models.MultipartField{
+					Name:  key,
+					Value: convertPlaceholders(field.Value),
+				})
+				continue
+			}
+
+			for _, file := range field.files() {
+				request.Multipart = append(request.Multipart, models.MultipartField{
+					Name:        key,
+					File:        file,
+					ContentType: field.ContentType,
+				})
+			}
+		}
+
+		// multipart sets the content type, along with the boundary, on its own
+		deleteHeader(request.Headers, "Content-Type")
+	case "file":
+		if b.File != nil && b.File.Src != "" {
+			request.BodyFile = &models.BodyFile{Path: b.File.Src}
+		}
+	case "graphql":
+		if b.GraphQL == nil {
+			return nil
+		}
+
+		request.GraphQL = &models.GraphQL{
+			Query:     convertPlaceholders(b.GraphQL.Query),
+			Variables: models.JSONBody{Text: convertPlaceholders(strings.TrimSpace(b.GraphQL.Variables))},
+		}
+	default:
+		return fmt.Errorf("%s bodies are not supported", b.Mode)
+	}
+
+	return nil
+}
+
+// functionPlaceholders are the placeholders calling functions that Postman dynamic variables
+// are converted to.
+var functionPlaceholders = map[string]string{
+	"$guid":         "{{ uuid }}",
+	"$randomUUID":   "{{ uuid }}",
+	"$timestamp":    "{{ now | unix }}",
+	"$isoTimestamp": "{{ now }}",
+	"$randomInt":    "{{ randomInt 0 1000 }}",
+}
+
+// convertPlaceholders converts {{name}} placeholders to yurl variables. Dynamic variables,
+// like {{$guid}}, become functions, the ones without an equivalent are left as they are.
+func convertPlaceholders(s string) string {
+	return placeholderRegex.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholderRegex.FindStringSubmatch(placeholder)[1]
+		if strings.HasPrefix(name, "$") {
+			if function, ok := functionPlaceholders[name]; ok {
+				return function
+			}
+
+			return placeholder
+		}
+
+		return "{{ " + variable.NormalizeKey(name) + " }}"
+	})
+}
+
+// lowerName converts the name of a folder or an environment to a camel cased
+// name, for example: Local Dev becomes localDev.
+func lowerName(name string) string {
+	name = variable.NormalizeKey(name)
+	if name == "" {
+		return ""
+	}
+
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func deleteHeader(headers map[string]string, name string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+}
+
+// headerValue returns the value of the header, ignoring the case of its name.
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -13,6 +13,8 @@ const (
 	SourceCLI         Source = "cli"
 	SourceInput       Source = "input"
 	SourceExports     Source = "request exports"
+	SourceEnvironment Source = "environment"
+	SourcePlaceholder Source = "placeholder"
 )
 
 type Variable struct {
@@ -31,6 +33,14 @@ func (vars Variables) Add(v Variable) {
 	vars[v.Key] = v
 }
 
+// Merge adds all the variables from other, overriding the ones
+// that already exist.
+func (vars Variables) Merge(other Variables) {
+	for _, v := range other {
+		vars.Add(v)
+	}
+}
+
 func (vars Variables) Remove(key string) {
 	delete(vars, key)
 }
@@ -40,6 +50,23 @@ func (vars Variables) Get(key string) (Variable, bool) {
 	return v, ok
 }
 
+// NormalizeKey converts s to a valid variable key by dropping all the characters
+// that can't be used in a key and camel casing the parts, for example base_url
+// becomes baseUrl.
+func NormalizeKey(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+
+	for i, part := range parts {
+		if i > 0 {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+
+	return strings.Join(parts, "")
+}
+
 type ErrInvalidFormat struct {
 	format string
 }
@@ -49,14 +76,15 @@ func (e ErrInvalidFormat) Error() string {
 }
 
 func ParseStringWithSource(v string, source Source) (Variable, error) {
-	parts := strings.Split(v, "=")
+	// Values can contain '=', for example base64 padding or query strings
+	parts := strings.SplitN(v, "=", 2)
 
 	if len(parts) != 2 {
 		return Variable{}, ErrInvalidFormat{format: v}
 	}
 
 	key := parts[0]
-	value := strings.Join(parts[1:], "=")
+	value := parts[1]
 	return Variable{
 		Key:    key,
 		Value:  value,
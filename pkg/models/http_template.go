@@ -1,111 +1,752 @@
 package models
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gurleensethi/yurl/internal/variable"
+	"gopkg.in/yaml.v3"
 )
 
 type HttpTemplate struct {
-	Config   Config                         `yaml:"config"`
-	Requests map[string]HttpRequestTemplate `yaml:"requests"`
+	Include []Include `yaml:"include,omitempty"`
+	Config  Config    `yaml:"config,omitempty"`
+
+	// Variables are added to the variable set with the lowest precedence.
+	Variables map[string]string `yaml:"variables,omitempty"`
+
+	Requests map[string]HttpRequestTemplate `yaml:"requests,omitempty"`
+
+	// Dir is the directory of the root file, paths in the config are relative to it.
+	Dir string `yaml:"-"`
+}
+
+// Include is another request file whose requests are merged into
+// the template under a namespace.
+type Include struct {
+	// Path of the file, relative to the file that includes it.
+	Path string `yaml:"path,omitempty"`
+
+	// Namespace the requests are merged under. Defaults to the
+	// name of the file without its extension.
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// UnmarshalYAML allows an include to be written either as just
+// the path or as a mapping with path and namespace.
+func (i *Include) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		i.Path = node.Value
+		return nil
+	}
+
+	type include Include
+	return node.Decode((*include)(i))
+}
+
+// QualifiedName returns the name of a request inside the namespace.
+func QualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "." + name
+}
+
+// RouteName makes a request name from the method and url (or path),
+// for example: POST /users/{id}/posts becomes PostUsersIdPosts.
+func RouteName(method string, rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+
+	name := variable.NormalizeKey(strings.ToLower(method) + " " + path)
+
+	return strings.ToUpper(name[:1]) + name[1:]
 }
 
 func (t *HttpTemplate) Sanitize() {
 	t.Config.Sanitize()
 
-	for _, request := range t.Requests {
+	for name, request := range t.Requests {
 		request.Sanitize()
+		t.Requests[name] = request
+	}
+}
+
+// Problem is an issue found while validating a template.
+type Problem struct {
+	// Path is the location of the problem in the template, made of the keys
+	// and indexes leading to it, for example: requests, GetTodo, pre, 0, name.
+	Path    []string
+	Message string
+}
+
+func (p Problem) Error() string {
+	return p.Message
+}
+
+func newProblem(path []string, format string, a ...any) Problem {
+	return Problem{
+		Path:    path,
+		Message: fmt.Sprintf(format, a...),
 	}
 }
 
 func (t *HttpTemplate) Validate() error {
-	err := t.Config.Validate()
-	if err != nil {
-		return err
+	problems := t.Problems()
+	if len(problems) > 0 {
+		return problems[0]
 	}
 
+	return nil
+}
+
+// Problems returns all the problems found in the template, unlike Validate
+// it doesn't stop at the first one.
+func (t *HttpTemplate) Problems() []Problem {
+	problems := t.Config.Problems()
+
+	names := make([]string, 0, len(t.Requests))
+	for name := range t.Requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		problems = append(problems, t.requestProblems(t.Requests[name])...)
+	}
+
+	problems = append(problems, t.extendsProblems(names)...)
+
 	// DFS to check any cycles on pre requests
-	for _, request := range t.Requests {
-		err := t.findPreRequestCycles(request.Name, make([]string, 0), make(map[string]struct{}))
+	checked := make(map[string]struct{})
+	for _, name := range names {
+		err := t.findPreRequestCycles(name, make([]string, 0), checked)
 		if err != nil {
-			return err
+			problems = append(problems, err.(Problem))
 		}
 	}
 
-	return nil
+	// Same problem can be found from multiple requests, for example a cycle.
+	unique := make([]Problem, 0, len(problems))
+	seen := make(map[string]struct{})
+	for _, problem := range problems {
+		key := strings.Join(problem.Path, ".") + ":" + problem.Message
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		unique = append(unique, problem)
+	}
+
+	return unique
+}
+
+// requestProblems returns problems with the request itself.
+func (t *HttpTemplate) requestProblems(request HttpRequestTemplate) []Problem {
+	var problems []Problem
+	path := []string{"requests", request.Name}
+
+	if request.Method != "" && !slices.Contains(Methods, strings.ToUpper(request.Method)) {
+		problems = append(problems, newProblem(append(path, "method"), "'%s' has an invalid method '%s'", request.Name, request.Method))
+	}
+
+	if bodies := request.bodies(); len(bodies) > 1 {
+		problems = append(problems, newProblem(path, "'%s' can only have one of %s", request.Name, strings.Join(bodies, ", ")))
+	}
+
+	for i, preRequest := range request.PreRequests {
+		prePath := []string{"requests", request.Name, "pre", strconv.Itoa(i), "name"}
+
+		// Check if pre request is defined
+		preRequestTemplate, ok := t.Requests[preRequest.Name]
+		if !ok {
+			problems = append(problems, newProblem(prePath, "'%s' has a pre-request named '%s' which is not defined", request.Name, preRequest.Name))
+			continue
+		}
+
+		if preRequestTemplate.Abstract {
+			problems = append(problems, newProblem(prePath, "'%s' has a pre-request named '%s' which is abstract", request.Name, preRequest.Name))
+		}
+	}
+
+	for i, field := range request.Multipart {
+		fieldPath := append(path, "multipart", strconv.Itoa(i))
+
+		if field.Name == "" {
+			problems = append(problems, newProblem(fieldPath, "'%s' has a multipart field without a name", request.Name))
+		}
+
+		if field.Value != "" && field.File != "" {
+			problems = append(problems, newProblem(fieldPath, "'%s' has a multipart field '%s' with both value and file", request.Name, field.Name))
+		}
+	}
+
+	for _, name := range sortedKeys(request.Exports) {
+		if export := request.Exports[name]; export.JSON != "" && export.XPath != "" {
+			problems = append(problems, newProblem(append(path, "exports", name), "'%s' has an export '%s' with both json and xpath", request.Name, name))
+		}
+	}
+
+	if request.GraphQL != nil && request.GraphQL.Query == "" {
+		problems = append(problems, newProblem(append(path, "graphql"), "'%s' has a graphql operation without a query", request.Name))
+	}
+
+	if request.WebSocket != nil {
+		problems = append(problems, webSocketProblems(request)...)
+	}
+
+	if request.Stream != nil {
+		problems = append(problems, streamProblems(request)...)
+	}
+
+	if request.SaveTo != nil {
+		savePath := append(path, "saveTo")
+
+		switch {
+		case request.SaveTo.Path == "":
+			problems = append(problems, newProblem(savePath, "'%s' has a saveTo without a path", request.Name))
+		case len(request.Exports) > 0:
+			problems = append(problems, newProblem(savePath, "'%s' saves its response to a file and can't have exports", request.Name))
+		case request.WebSocket != nil:
+			problems = append(problems, newProblem(savePath, "'%s' is a websocket and can't be saved to a file", request.Name))
+		}
+	}
+
+	if request.BodyFile != nil && request.BodyFile.Path == "" {
+		problems = append(problems, newProblem(append(path, "bodyFile"), "'%s' has a bodyFile without a path", request.Name))
+	}
+
+	if request.URL != "" && request.Service != "" {
+		problems = append(problems, newProblem(path, "'%s' can't have both url and service", request.Name))
+	}
+
+	if request.Service != "" {
+		if _, ok := t.Config.Services[request.Service]; !ok {
+			problems = append(problems, newProblem(append(path, "service"), "'%s' uses a service named '%s' which is not defined", request.Name, request.Service))
+		}
+	}
+
+	// Requests that are not sent to a service or an absolute url use the host from config.
+	if request.URL == "" && request.Service == "" && !request.Abstract && t.Config.Host == "" {
+		problems = append(problems, newProblem([]string{"config"}, "config.host or config.socket is required"))
+	}
+
+	return problems
 }
 
-// findPreRequestCycles checks if there are any cycles in pre request chain.
-func (t *HttpTemplate) findPreRequestCycles(requestName string, requestChain []string, visited map[string]struct{}) error {
+// findPreRequestCycles checks if there are any cycles in pre request chain. Requests
+// that are fully checked are added to checked so that they are not checked again.
+func (t *HttpTemplate) findPreRequestCycles(requestName string, requestChain []string, checked map[string]struct{}) error {
+	if _, ok := checked[requestName]; ok {
+		return nil
+	}
+
 	request := t.Requests[requestName]
-	visited[requestName] = struct{}{}
 	requestChain = append(requestChain, requestName)
 
 	for _, preRequest := range request.PreRequests {
-		// Check if pre request is defined
+		// Undefined pre requests are reported with the request itself
 		if _, ok := t.Requests[preRequest.Name]; !ok {
-			return fmt.Errorf("'%s' has a pre-request named '%s' which is not defined", requestName, preRequest.Name)
+			continue
 		}
 
-		if _, ok := visited[preRequest.Name]; ok {
-			return fmt.Errorf("cycle detected in pre-requests chain: %s", strings.Join(append(requestChain, preRequest.Name), " -> "))
+		if index := slices.Index(requestChain, preRequest.Name); index != -1 {
+			cycle := rotateCycle(requestChain[index:])
+			return newProblem([]string{"requests", cycle[0], "pre"}, "cycle detected in pre-requests chain: %s", strings.Join(append(cycle, cycle[0]), " -> "))
 		}
 
-		err := t.findPreRequestCycles(preRequest.Name, requestChain, visited)
+		err := t.findPreRequestCycles(preRequest.Name, requestChain, checked)
 		if err != nil {
 			return err
 		}
 	}
 
+	checked[requestName] = struct{}{}
+
 	return nil
 }
 
+// rotateCycle rotates the requests in a cycle to start from the one with the smallest name,
+// so that a cycle is always reported the same regardless of where it was found from.
+func rotateCycle(cycle []string) []string {
+	start := slices.Index(cycle, slices.Min(cycle))
+	return append(slices.Clone(cycle[start:]), cycle[:start]...)
+}
+
+// ResolveExtends merges every request with the request it extends. Requests
+// inherit method, url, service, socket, headers, query, exports and pre-requests
+// from their parent, values defined on the request itself take precedence.
+func (t *HttpTemplate) ResolveExtends() error {
+	names := make([]string, 0, len(t.Requests))
+	for name := range t.Requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := t.extendsProblems(names)
+	if len(problems) > 0 {
+		return problems[0]
+	}
+
+	resolved := make(map[string]HttpRequestTemplate, len(t.Requests))
+	for name := range t.Requests {
+		t.resolveExtends(name, resolved)
+	}
+
+	t.Requests = resolved
+
+	return nil
+}
+
+// extendsProblems returns the problems in extends chains of the requests.
+func (t *HttpTemplate) extendsProblems(names []string) []Problem {
+	var problems []Problem
+
+	for _, name := range names {
+		err := t.findExtendsCycles(name, make([]string, 0))
+		if err != nil {
+			problems = append(problems, err.(Problem))
+		}
+	}
+
+	return problems
+}
+
+// resolveExtends resolves the request along with all of its parents, resolved
+// requests are stored in resolved so that each request is resolved only once.
+func (t *HttpTemplate) resolveExtends(requestName string, resolved map[string]HttpRequestTemplate) HttpRequestTemplate {
+	if request, ok := resolved[requestName]; ok {
+		return request
+	}
+
+	request := t.Requests[requestName]
+	if request.Extends != "" {
+		request.inherit(t.resolveExtends(request.Extends, resolved))
+	}
+
+	resolved[requestName] = request
+
+	return request
+}
+
+// findExtendsCycles checks if there are any cycles in extends chain.
+func (t *HttpTemplate) findExtendsCycles(requestName string, requestChain []string) error {
+	request := t.Requests[requestName]
+	requestChain = append(requestChain, requestName)
+
+	if request.Extends == "" {
+		return nil
+	}
+
+	path := []string{"requests", requestName, "extends"}
+
+	// Check if parent request is defined
+	if _, ok := t.Requests[request.Extends]; !ok {
+		return newProblem(path, "'%s' extends '%s' which is not defined", requestName, request.Extends)
+	}
+
+	if index := slices.Index(requestChain, request.Extends); index != -1 {
+		cycle := rotateCycle(requestChain[index:])
+		return newProblem([]string{"requests", cycle[0], "extends"}, "cycle detected in extends chain: %s", strings.Join(append(cycle, cycle[0]), " -> "))
+	}
+
+	return t.findExtendsCycles(request.Extends, requestChain)
+}
+
 type Config struct {
-	Host   string `yaml:"host"`
-	Port   int    `yaml:"port"`
-	Scheme string `yaml:"scheme"`
+	Host   string `yaml:"host,omitempty"`
+	Port   int    `yaml:"port,omitempty"`
+	Scheme string `yaml:"scheme,omitempty"`
+
+	// BasePath is prefixed to the path of every request.
+	BasePath string `yaml:"basePath,omitempty"`
+
+	// Socket is the path of a unix domain socket requests are sent over, for example
+	// /var/run/docker.sock. Host defaults to localhost and is only sent in the Host header.
+	Socket string `yaml:"socket,omitempty"`
+
+	// Headers and Query are the defaults for every request,
+	// values defined on a request take precedence.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Query   map[string]string `yaml:"query,omitempty"`
+
+	// Services are hosts, other than the default one, that requests can be sent to.
+	Services map[string]Service `yaml:"services,omitempty"`
+
+	Environments map[string]Environment `yaml:"environments,omitempty"`
 }
 
 func (c Config) Validate() error {
-	if c.Host == "" {
-		return fmt.Errorf("config.host is required")
+	problems := c.Problems()
+	if len(problems) > 0 {
+		return problems[0]
 	}
 
+	return nil
+}
+
+// Problems returns all the problems found in the config.
+func (c Config) Problems() []Problem {
+	var problems []Problem
+
 	if c.Scheme != "http" && c.Scheme != "https" {
-		return fmt.Errorf("config.scheme must be http or https")
+		problems = append(problems, newProblem([]string{"config", "scheme"}, "config.scheme must be http or https"))
 	}
 
-	return nil
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		service := c.Services[name]
+		path := []string{"config", "services", name}
+
+		if service.Host == "" {
+			problems = append(problems, newProblem(path, "config.services.%s.host or config.services.%s.socket is required", name, name))
+		}
+
+		if service.Scheme != "http" && service.Scheme != "https" {
+			problems = append(problems, newProblem(append(path, "scheme"), "config.services.%s.scheme must be http or https", name))
+		}
+	}
+
+	return problems
 }
 
 func (c *Config) Sanitize() {
 	if c.Scheme == "" {
 		c.Scheme = "http"
 	}
+
+	if c.Host == "" && c.Socket != "" {
+		c.Host = "localhost"
+	}
+
+	for name, service := range c.Services {
+		if service.Scheme == "" {
+			service.Scheme = c.Scheme
+		}
+
+		if service.Host == "" && service.Socket != "" {
+			service.Host = "localhost"
+		}
+
+		c.Services[name] = service
+	}
+}
+
+// Service returns the service with the given name. Empty name
+// returns the default service made from host of the config.
+func (c Config) Service(name string) (Service, bool) {
+	if name == "" {
+		return Service{
+			Host:     c.Host,
+			Port:     c.Port,
+			Scheme:   c.Scheme,
+			BasePath: c.BasePath,
+			Socket:   c.Socket,
+		}, true
+	}
+
+	service, ok := c.Services[name]
+	return service, ok
+}
+
+// UseEnvironment overrides the config with values from the environment
+// with the given name and returns the selected environment.
+func (c *Config) UseEnvironment(name string) (Environment, error) {
+	env, ok := c.Environments[name]
+	if !ok {
+		return Environment{}, fmt.Errorf("environment '%s' is not defined", name)
+	}
+
+	if env.Host != "" {
+		c.Host = env.Host
+	}
+
+	if env.Port != 0 {
+		c.Port = env.Port
+	}
+
+	if env.Scheme != "" {
+		c.Scheme = env.Scheme
+	}
+
+	if env.BasePath != "" {
+		c.BasePath = env.BasePath
+	}
+
+	if env.Socket != "" {
+		c.Socket = env.Socket
+	}
+
+	for serviceName, override := range env.Services {
+		if c.Services == nil {
+			c.Services = make(map[string]Service)
+		}
+
+		service := c.Services[serviceName]
+		service.override(override)
+		c.Services[serviceName] = service
+	}
+
+	return env, nil
+}
+
+// Environment is a named set of overrides for the config, for example
+// dev, staging or prod.
+type Environment struct {
+	Host      string             `yaml:"host,omitempty"`
+	Port      int                `yaml:"port,omitempty"`
+	Scheme    string             `yaml:"scheme,omitempty"`
+	BasePath  string             `yaml:"basePath,omitempty"`
+	Socket    string             `yaml:"socket,omitempty"`
+	Services  map[string]Service `yaml:"services,omitempty"`
+	Variables map[string]string  `yaml:"variables,omitempty"`
+}
+
+// Service is a host that requests can be sent to.
+type Service struct {
+	Host     string `yaml:"host,omitempty"`
+	Port     int    `yaml:"port,omitempty"`
+	Scheme   string `yaml:"scheme,omitempty"`
+	BasePath string `yaml:"basePath,omitempty"`
+	Socket   string `yaml:"socket,omitempty"`
+}
+
+// override replaces the values of the service with the non empty values of other.
+func (s *Service) override(other Service) {
+	if other.Host != "" {
+		s.Host = other.Host
+	}
+
+	if other.Port != 0 {
+		s.Port = other.Port
+	}
+
+	if other.Scheme != "" {
+		s.Scheme = other.Scheme
+	}
+
+	if other.BasePath != "" {
+		s.BasePath = other.BasePath
+	}
+
+	if other.Socket != "" {
+		s.Socket = other.Socket
+	}
+}
+
+// Address returns host of the service along with the port, if any.
+func (s Service) Address() string {
+	if s.Port != 0 {
+		return fmt.Sprintf("%s:%d", s.Host, s.Port)
+	}
+
+	return s.Host
 }
 
 type PreRequest struct {
-	Name string `yaml:"name"`
+	Name string `yaml:"name,omitempty"`
 }
 
+// Export is a value read from the response, either using a JSONPath or an XPath.
 type Export struct {
-	JSON string `yaml:"json"`
+	JSON  string `yaml:"json,omitempty"`
+	XPath string `yaml:"xpath,omitempty"`
+}
+
+// Methods are the http methods a request can use.
+var Methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
 }
 
 type HttpRequestTemplate struct {
-	Name        string
-	Description string            `yaml:"description"`
-	Method      string            `yaml:"method"`
-	Path        string            `yaml:"path"`
-	Body        string            `yaml:"body"`
-	JsonBody    string            `yaml:"jsonBody"`
-	Headers     map[string]string `yaml:"headers"`
-	Query       map[string]string `yaml:"query"`
-	PreRequests []PreRequest      `yaml:"pre"`
-	Exports     map[string]Export `yaml:"exports"`
+	Name        string            `yaml:"-"`
+	Description string            `yaml:"description,omitempty"`
+	Extends     string            `yaml:"extends,omitempty"`
+	Abstract    bool              `yaml:"abstract,omitempty"`
+	Method      string            `yaml:"method,omitempty"`
+	URL         string            `yaml:"url,omitempty"`
+	Service     string            `yaml:"service,omitempty"`
+	Socket      string            `yaml:"socket,omitempty"`
+	Path        string            `yaml:"path,omitempty"`
+	Body        string            `yaml:"body,omitempty"`
+	JsonBody    JSONBody          `yaml:"jsonBody,omitempty"`
+	XmlBody     string            `yaml:"xmlBody,omitempty"`
+	GraphQL     *GraphQL          `yaml:"graphql,omitempty"`
+	WebSocket   *WebSocket        `yaml:"websocket,omitempty"`
+	Stream      *Stream           `yaml:"stream,omitempty"`
+	SaveTo      *SaveTo           `yaml:"saveTo,omitempty"`
+	FormBody    FormBody          `yaml:"formBody,omitempty"`
+	Multipart   []MultipartField  `yaml:"multipart,omitempty"`
+	BodyFile    *BodyFile         `yaml:"bodyFile,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Query       map[string]string `yaml:"query,omitempty"`
+	PreRequests []PreRequest      `yaml:"pre,omitempty"`
+	Exports     map[string]Export `yaml:"exports,omitempty"`
+
+	// Dir is the directory of the file the request is defined in,
+	// paths of files used by the request are relative to it.
+	Dir string `yaml:"-"`
+}
+
+// FormBody is a body sent as application/x-www-form-urlencoded, a field
+// can have multiple values.
+type FormBody map[string]StringList
+
+// NewFormBody makes a form body from the values.
+func NewFormBody(values url.Values) FormBody {
+	if len(values) == 0 {
+		return nil
+	}
+
+	form := make(FormBody, len(values))
+	for key, fieldValues := range values {
+		form[key] = StringList(fieldValues)
+	}
+
+	return form
+}
+
+// StringList is a list of strings that can also be written as a single string.
+type StringList []string
+
+// UnmarshalYAML allows the list to be written either as a scalar or a sequence.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+
+	*l = values
+
+	return nil
+}
+
+// MarshalYAML writes a list with a single value as a scalar.
+func (l StringList) MarshalYAML() (any, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+
+	return []string(l), nil
+}
+
+// MultipartField is a part of a multipart/form-data body, either a text field or a file.
+type MultipartField struct {
+	Name  string `yaml:"name,omitempty"`
+	Value string `yaml:"value,omitempty"`
+
+	// File is the path of the file sent as the part.
+	File string `yaml:"file,omitempty"`
+
+	// Filename and ContentType of a file part, they default to the name of
+	// the file and the type guessed from its extension.
+	Filename    string `yaml:"filename,omitempty"`
+	ContentType string `yaml:"contentType,omitempty"`
+}
+
+// GraphQL is a GraphQL operation, posted as json. Responses with errors fail the request
+// and exports are read from the data of the response.
+type GraphQL struct {
+	Query         string `yaml:"query,omitempty"`
+	OperationName string `yaml:"operationName,omitempty"`
+
+	// Variables of the operation, written the same way as a json body.
+	Variables JSONBody `yaml:"variables,omitempty"`
+}
+
+// BodyFile is a file sent as the body of the request.
+type BodyFile struct {
+	// Path of the file, relative to the file the request is defined in.
+	Path string `yaml:"path,omitempty"`
+
+	// Template replaces the variables in the content of the file. Without it,
+	// the file is streamed as it is.
+	Template bool `yaml:"template,omitempty"`
+}
+
+// UnmarshalYAML allows the body file to be written either as just
+// the path or as a mapping with path and template.
+func (f *BodyFile) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Path = node.Value
+		return nil
+	}
+
+	type bodyFile BodyFile
+	return node.Decode((*bodyFile)(f))
+}
+
+// MarshalYAML writes a body file that isn't a template as just the path.
+func (f BodyFile) MarshalYAML() (any, error) {
+	if !f.Template {
+		return f.Path, nil
+	}
+
+	type bodyFile BodyFile
+	return bodyFile(f), nil
+}
+
+// SaveTo is a file the body of the response is written to, instead of being printed.
+type SaveTo struct {
+	// Path of the file, relative to the file the request is defined in.
+	Path string `yaml:"path,omitempty"`
+
+	// Resume continues the download of a file that was partially written,
+	// asking the server for the rest of the body using a Range request.
+	Resume bool `yaml:"resume,omitempty"`
+}
+
+// UnmarshalYAML allows the file to be written either as just
+// the path or as a mapping with path and resume.
+func (s *SaveTo) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Path = node.Value
+		return nil
+	}
+
+	type saveTo SaveTo
+	return node.Decode((*saveTo)(s))
+}
+
+// MarshalYAML writes a file that isn't resumed as just the path.
+func (s SaveTo) MarshalYAML() (any, error) {
+	if !s.Resume {
+		return s.Path, nil
+	}
+
+	type saveTo SaveTo
+	return saveTo(s), nil
 }
 
 type HttpRequest struct {
@@ -119,10 +760,64 @@ type HttpResponse struct {
 	RawResponse *http.Response
 	RawBody     []byte
 	Exports     map[string]any
+
+	// Streamed is set when the body was read as a stream of events,
+	// which are printed as they arrive.
+	Streamed bool
+
+	// SavedTo is the file the body was written to, RawBody is empty then.
+	// SavedSize is the number of bytes written to it.
+	SavedTo   string
+	SavedSize int64
+
+	// StartedAt is when the request was sent and Duration is the time
+	// taken until the whole response body was read.
+	StartedAt time.Time
+	Duration  time.Duration
+
+	// Messages are the messages sent and received by a websocket request, in order.
+	// RawResponse is nil for websockets, the handshake response is not kept.
+	Messages []WebSocketMessage
+}
+
+// WebSocketMessage is a message sent or received over a websocket.
+type WebSocketMessage struct {
+	Sent bool
+	Time time.Time
+	Data string
+}
+
+// binarySampleSize is the number of bytes of the body looked at to tell if it is binary.
+const binarySampleSize = 8000
+
+// IsBinary reports whether the body is binary, like an image or an archive, rather than
+// text. Bodies with a NUL byte, or which are not valid UTF-8, are binary.
+func (r *HttpResponse) IsBinary() bool {
+	sample := r.RawBody[:min(len(r.RawBody), binarySampleSize)]
+
+	if bytes.IndexByte(sample, 0) != -1 {
+		return true
+	}
+
+	for i := 0; i < len(sample); {
+		char, size := utf8.DecodeRune(sample[i:])
+
+		// A character cut by the end of the sample is not invalid
+		if char == utf8.RuneError && size == 1 && len(sample)-i >= utf8.UTFMax {
+			return true
+		}
+
+		i += size
+	}
+
+	return false
 }
 
 func (r *HttpRequestTemplate) Sanitize() {
 	r.Method = strings.ToUpper(r.Method)
+	if r.Method == "" && r.GraphQL != nil {
+		r.Method = "POST"
+	}
 	if r.Method == "" {
 		r.Method = "GET"
 	}
@@ -131,3 +826,106 @@ func (r *HttpRequestTemplate) Sanitize() {
 		r.Path = "/"
 	}
 }
+
+// bodies returns the keys of all the bodies set on the request.
+func (r *HttpRequestTemplate) bodies() []string {
+	var bodies []string
+
+	if r.Body != "" {
+		bodies = append(bodies, "body")
+	}
+
+	if !r.JsonBody.IsZero() {
+		bodies = append(bodies, "jsonBody")
+	}
+
+	if r.XmlBody != "" {
+		bodies = append(bodies, "xmlBody")
+	}
+
+	if r.GraphQL != nil {
+		bodies = append(bodies, "graphql")
+	}
+
+	if len(r.FormBody) > 0 {
+		bodies = append(bodies, "formBody")
+	}
+
+	if len(r.Multipart) > 0 {
+		bodies = append(bodies, "multipart")
+	}
+
+	if r.BodyFile != nil {
+		bodies = append(bodies, "bodyFile")
+	}
+
+	return bodies
+}
+
+// inherit fills the request with values from the parent. Maps are merged key by key
+// and pre-requests of the parent are executed before the ones of the request.
+func (r *HttpRequestTemplate) inherit(parent HttpRequestTemplate) {
+	if r.Method == "" {
+		r.Method = parent.Method
+	}
+
+	// Where the request is sent is inherited as a whole, a request sent to a service
+	// doesn't inherit the url or the socket of its parent.
+	if r.URL == "" && r.Service == "" && r.Socket == "" {
+		r.URL = parent.URL
+		r.Service = parent.Service
+		r.Socket = parent.Socket
+
+		// Sockets are relative to the file the parent is defined in, which may be another file
+		if r.Socket != "" && r.Dir != parent.Dir && !filepath.IsAbs(r.Socket) && !strings.Contains(r.Socket, "{{") {
+			if socket, err := filepath.Abs(filepath.Join(parent.Dir, r.Socket)); err == nil {
+				r.Socket = socket
+			}
+		}
+	}
+
+	r.Headers = mergeMaps(parent.Headers, r.Headers)
+	r.Query = mergeMaps(parent.Query, r.Query)
+	r.Exports = mergeMaps(parent.Exports, r.Exports)
+
+	preRequests := make([]PreRequest, 0, len(parent.PreRequests)+len(r.PreRequests))
+	for i, preRequest := range append(slices.Clip(parent.PreRequests), r.PreRequests...) {
+		// A pre-request extending the same parent would inherit itself as a pre-request
+		inherited := i < len(parent.PreRequests)
+		if inherited && preRequest.Name == r.Name {
+			continue
+		}
+
+		if !slices.Contains(preRequests, preRequest) {
+			preRequests = append(preRequests, preRequest)
+		}
+	}
+	r.PreRequests = preRequests
+}
+
+// mergeMaps returns a new map with all the values from base overridden by values from override.
+func mergeMaps[V any](base, override map[string]V) map[string]V {
+	if base == nil && override == nil {
+		return nil
+	}
+
+	merged := make(map[string]V, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONBody is a json body written either as a string of json, or as a yaml
+// mapping or sequence that is converted to json.
+type JSONBody struct {
+	// Text is the body written as a string.
+	Text string
+
+	// Node is the body written as yaml, it is nil when Text is used.
+	Node *yaml.Node
+}
+
+// UnmarshalYAML allows the body to be written either as a string or as yaml.
+func (b *JSONBody) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() != "!!null" {
+			b.Text = node.Value
+		}
+		return nil
+	}
+
+	b.Node = node
+
+	return nil
+}
+
+func (b JSONBody) MarshalYAML() (any, error) {
+	if b.Node != nil {
+		return b.Node, nil
+	}
+
+	return b.Text, nil
+}
+
+// IsZero reports whether the body is not set.
+func (b JSONBody) IsZero() bool {
+	return b.Text == "" && b.Node == nil
+}
+
+// Render converts the body to indented json. Scalars of the yaml are converted by scalar,
+// which returns their json, see JSONScalar. Bodies written as a string are returned as they are.
+func (b JSONBody) Render(scalar func(node *yaml.Node) (string, error)) (string, error) {
+	if b.Node == nil {
+		return b.Text, nil
+	}
+
+	var out strings.Builder
+
+	err := renderJSON(&out, b.Node, "", scalar)
+	if err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+func renderJSON(out *strings.Builder, node *yaml.Node, indent string, scalar func(node *yaml.Node) (string, error)) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return renderJSON(out, node.Content[0], indent, scalar)
+	case yaml.AliasNode:
+		return renderJSON(out, node.Alias, indent, scalar)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			out.WriteString("{}")
+			return nil
+		}
+
+		out.WriteString("{")
+		for i := 0; i < len(node.Content); i += 2 {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n" + indent + "  ")
+
+			key, err := scalar(node.Content[i])
+			if err != nil {
+				return err
+			}
+
+			if !strings.HasPrefix(key, `"`) {
+				return fmt.Errorf("line %d: keys of json objects must be strings", node.Content[i].Line)
+			}
+
+			out.WriteString(key + ": ")
+
+			err = renderJSON(out, node.Content[i+1], indent+"  ", scalar)
+			if err != nil {
+				return err
+			}
+		}
+		out.WriteString("\n" + indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			out.WriteString("[]")
+			return nil
+		}
+
+		out.WriteString("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n" + indent + "  ")
+
+			err := renderJSON(out, item, indent+"  ", scalar)
+			if err != nil {
+				return err
+			}
+		}
+		out.WriteString("\n" + indent + "]")
+	case yaml.ScalarNode:
+		value, err := scalar(node)
+		if err != nil {
+			return err
+		}
+
+		out.WriteString(value)
+	}
+
+	return nil
+}
+
+// JSONScalar converts the yaml scalar to json, keeping its type.
+func JSONScalar(node *yaml.Node) (string, error) {
+	if node.ShortTag() == "!!str" {
+		return MarshalJSON(node.Value)
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+
+	content, err := MarshalJSON(value)
+	if err != nil {
+		return "", fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	return content, nil
+}
+
+// MarshalJSON encodes the value as json without escaping html characters, which
+// are kept as they are in the body.
+func MarshalJSON(value any) (string, error) {
+	var b bytes.Buffer
+
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
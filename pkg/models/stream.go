@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Values of Stream.ExportFrom, the event exports are read from.
+const (
+	ExportFromFirst = "first"
+	ExportFromLast  = "last"
+	ExportFromUntil = "until"
+)
+
+// Stream reads the response as a stream of events, printing each event as it arrives.
+// Server-sent events are split on blank lines, other responses, like NDJSON, on new lines.
+type Stream struct {
+	// Disabled turns off the detection of streamed responses, set by stream: false.
+	Disabled bool `yaml:"-"`
+
+	// MaxEvents stops the stream once that many events are read.
+	MaxEvents int `yaml:"maxEvents,omitempty"`
+
+	// Timeout stops the stream once it has been read for that long, for example 30s.
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// Until stops the stream at the first event it matches.
+	Until *Expect `yaml:"until,omitempty"`
+
+	// ExportFrom is the event exports are read from: first, last or until. The event
+	// matching Until is used when it is set, the last event otherwise.
+	ExportFrom string `yaml:"exportFrom,omitempty"`
+}
+
+// UnmarshalYAML allows the stream to be written either as true or false, or as a mapping.
+func (s *Stream) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return err
+		}
+
+		s.Disabled = !enabled
+		return nil
+	}
+
+	type stream Stream
+	return node.Decode((*stream)(s))
+}
+
+// MarshalYAML writes a stream without options as true or false.
+func (s Stream) MarshalYAML() (any, error) {
+	if s.Disabled {
+		return false, nil
+	}
+
+	if s == (Stream{}) {
+		return true, nil
+	}
+
+	type stream Stream
+	return stream(s), nil
+}
+
+// TimeoutDuration returns the timeout of the stream, zero when it is not set.
+func (s Stream) TimeoutDuration() (time.Duration, error) {
+	if s.Timeout == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(s.Timeout)
+}
+
+// EventSource returns the event exports are read from.
+func (s Stream) EventSource() string {
+	switch {
+	case s.ExportFrom != "":
+		return s.ExportFrom
+	case s.Until != nil:
+		return ExportFromUntil
+	}
+
+	return ExportFromLast
+}
+
+// streamProblems returns the problems with the stream options of the request.
+func streamProblems(request HttpRequestTemplate) []Problem {
+	var problems []Problem
+	path := []string{"requests", request.Name, "stream"}
+	stream := request.Stream
+
+	if stream.Disabled {
+		return nil
+	}
+
+	if _, err := stream.TimeoutDuration(); err != nil {
+		problems = append(problems, newProblem(append(path, "timeout"), "'%s' has an invalid stream timeout '%s'", request.Name, stream.Timeout))
+	}
+
+	if stream.MaxEvents < 0 {
+		problems = append(problems, newProblem(append(path, "maxEvents"), "'%s' has a negative maxEvents %d", request.Name, stream.MaxEvents))
+	}
+
+	switch stream.ExportFrom {
+	case "", ExportFromFirst, ExportFromLast:
+	case ExportFromUntil:
+		if stream.Until == nil {
+			problems = append(problems, newProblem(append(path, "exportFrom"), "'%s' exports from the until event but has no until", request.Name))
+		}
+	default:
+		problems = append(problems, newProblem(append(path, "exportFrom"), "'%s' has an invalid exportFrom '%s', expected first, last or until", request.Name, stream.ExportFrom))
+	}
+
+	if stream.Until != nil {
+		problems = append(problems, expectProblems(request.Name, append(path, "until"), *stream.Until)...)
+	}
+
+	if request.WebSocket != nil {
+		problems = append(problems, newProblem(path, "'%s' is a websocket and can't be streamed", request.Name))
+	}
+
+	return problems
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultWebSocketTimeout is how long an expect step waits for a matching message.
+const DefaultWebSocketTimeout = 10 * time.Second
+
+// WebSocket is a websocket session, the request is upgraded to a websocket
+// and the steps are run in order.
+type WebSocket struct {
+	// Timeout of each expect step, for example 30s, 10s when not set.
+	Timeout string          `yaml:"timeout,omitempty"`
+	Steps   []WebSocketStep `yaml:"steps"`
+}
+
+// TimeoutDuration returns the timeout of the expect steps.
+func (w WebSocket) TimeoutDuration() (time.Duration, error) {
+	if w.Timeout == "" {
+		return DefaultWebSocketTimeout, nil
+	}
+
+	return time.ParseDuration(w.Timeout)
+}
+
+// WebSocketStep either sends a message or waits for a message matching Expect.
+type WebSocketStep struct {
+	// Send is the message to send, written the same way as a json body, a
+	// string is sent as it is.
+	Send JSONBody `yaml:"send,omitempty"`
+
+	Expect *Expect `yaml:"expect,omitempty"`
+
+	// Exports are read from the message matching Expect.
+	Exports map[string]Export `yaml:"exports,omitempty"`
+}
+
+// Expect matches a message, or an event, either using a JSONPath, optionally
+// comparing the value it selects, or using a regex.
+type Expect struct {
+	JSON   string `yaml:"json,omitempty"`
+	Equals string `yaml:"equals,omitempty"`
+	Regex  string `yaml:"regex,omitempty"`
+}
+
+// webSocketProblems returns the problems with the websocket session of the request.
+func webSocketProblems(request HttpRequestTemplate) []Problem {
+	var problems []Problem
+	path := []string{"requests", request.Name, "websocket"}
+
+	if _, err := request.WebSocket.TimeoutDuration(); err != nil {
+		problems = append(problems, newProblem(append(path, "timeout"), "'%s' has an invalid websocket timeout '%s'", request.Name, request.WebSocket.Timeout))
+	}
+
+	if len(request.bodies()) > 0 {
+		problems = append(problems, newProblem(path, "'%s' is a websocket and can't have a body", request.Name))
+	}
+
+	if len(request.Exports) > 0 {
+		problems = append(problems, newProblem([]string{"requests", request.Name, "exports"}, "'%s' is a websocket, exports are read from its expect steps", request.Name))
+	}
+
+	for i, step := range request.WebSocket.Steps {
+		stepPath := []string{"requests", request.Name, "websocket", "steps", strconv.Itoa(i)}
+
+		if step.Send.IsZero() == (step.Expect == nil) {
+			problems = append(problems, newProblem(stepPath, "'%s' has a websocket step that must have one of send or expect", request.Name))
+			continue
+		}
+
+		if step.Expect == nil {
+			if len(step.Exports) > 0 {
+				problems = append(problems, newProblem(append(stepPath, "exports"), "'%s' has exports on a websocket step without expect", request.Name))
+			}
+			continue
+		}
+
+		problems = append(problems, expectProblems(request.Name, append(stepPath, "expect"), *step.Expect)...)
+
+		for _, name := range sortedKeys(step.Exports) {
+			if export := step.Exports[name]; export.JSON != "" && export.XPath != "" {
+				problems = append(problems, newProblem(append(stepPath, "exports", name), "'%s' has an export '%s' with both json and xpath", request.Name, name))
+			}
+		}
+	}
+
+	return problems
+}
+
+// expectProblems returns the problems with the matcher at path.
+func expectProblems(name string, path []string, expect Expect) []Problem {
+	switch {
+	case (expect.JSON == "") == (expect.Regex == ""):
+		return []Problem{newProblem(path, "'%s' has a matcher that must have one of json or regex", name)}
+	case expect.Equals != "" && expect.JSON == "":
+		return []Problem{newProblem(append(path, "equals"), "'%s' has a matcher with equals but without json", name)}
+	case expect.Regex != "":
+		if _, err := regexp.Compile(expect.Regex); err != nil {
+			return []Problem{newProblem(append(path, "regex"), "'%s' has an invalid regex: %s", name, err)}
+		}
+	}
+
+	return nil
+}